@@ -2,20 +2,26 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"io"
 	stdLog "log"
 	"log/slog"
 	"os"
 	"os/signal"
-	"time"
+	"strings"
 
 	"github.com/AleksandrVishniakov/jwt-auth/internal/configs"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/grants"
 	"github.com/AleksandrVishniakov/jwt-auth/internal/handlers"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/policy"
 	"github.com/AleksandrVishniakov/jwt-auth/internal/repository"
 	"github.com/AleksandrVishniakov/jwt-auth/internal/repository/db"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/revocation"
 	"github.com/AleksandrVishniakov/jwt-auth/internal/roles"
 	"github.com/AleksandrVishniakov/jwt-auth/internal/servers/httpserver"
 	"github.com/AleksandrVishniakov/jwt-auth/internal/tokenizer"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/tokenversion"
 	"github.com/AleksandrVishniakov/jwt-auth/internal/usecases"
 )
 
@@ -24,6 +30,27 @@ const (
 )
 
 func main() {
+	checkConfig := flag.Bool("check-config", false, "validate the roles file and exit without touching the database")
+	flag.Parse()
+
+	if *checkConfig {
+		_, err := loadRoles(
+			configs.MustParsePermissions(configPath),
+			configs.MustParseRoles(configPath),
+		)
+		if err != nil {
+			stdLog.Fatalf("Config is invalid: %s\n", err.Error())
+		}
+
+		_, err = loadPolicies(configs.MustParsePolicies(configPath))
+		if err != nil {
+			stdLog.Fatalf("Config is invalid: %s\n", err.Error())
+		}
+
+		stdLog.Printf("config %s is valid\n", configPath)
+		return
+	}
+
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
@@ -42,7 +69,21 @@ func run(
 	log *slog.Logger,
 	cfg *configs.Config,
 ) error {
+	permissionsList := configs.MustParsePermissions(configPath)
 	rolesList := configs.MustParseRoles(configPath)
+	clientsList := configs.MustParseClients(configPath)
+	policiesList := configs.MustParsePolicies(configPath)
+	organizationsList := configs.MustParseOrganizations(configPath)
+
+	roleDefs, err := loadRoles(permissionsList, rolesList)
+	if err != nil {
+		return err
+	}
+
+	policies, err := loadPolicies(policiesList)
+	if err != nil {
+		return err
+	}
 
 	database, err := repository.NewPostgresDB(&repository.DBConfigs{
 		Host:     cfg.DB.Host,
@@ -58,25 +99,121 @@ func run(
 
 	queries := db.New(database)
 	repo := repository.New(log, database, queries)
-	tokenGenerator := tokenizer.New([]byte(cfg.JWTSignature), time.Hour)
-	roleManager := roles.NewManager(log, repo)
+	if cfg.JWT.RotationGracePeriod < cfg.Tokens.AccessTTL {
+		return errors.New("JWT_ROTATION_GRACE_PERIOD must not be shorter than ACCESS_TOKEN_TTL")
+	}
+
+	seedKey, err := signingKey(&cfg.JWT)
+	if err != nil {
+		return err
+	}
+
+	keyring := tokenizer.NewKeyring(
+		log,
+		repo,
+		cfg.JWT.Algorithm,
+		cfg.JWT.RotationInterval,
+		cfg.JWT.RotationGracePeriod,
+		// Other instances pick a rotated key up on their next check and
+		// verifiers on their next key set fetch.
+		handlers.KeySetMaxAge+cfg.JWT.RotationCheckInterval,
+	)
+	if err := keyring.Init(ctx, seedKey); err != nil {
+		return err
+	}
+
+	go keyring.Run(ctx, cfg.JWT.RotationCheckInterval)
+
+	clientAudiences := make(map[string][]string, len(clientsList))
+	for clientID, client := range clientsList {
+		clientAudiences[clientID] = client.Audiences
+	}
+
+	tokenGenerator := tokenizer.New(
+		keyring,
+		cfg.Tokens.AccessTTL,
+		cfg.JWT.Issuer,
+		cfg.JWT.Audience,
+		clientAudiences,
+		cfg.JWT.Leeway,
+		cfg.JWT.LegacyMaskUntil,
+	)
+	roleManager := roles.NewManager(log, repo, repo)
+
+	if err := roleManager.SyncPermissions(ctx); err != nil {
+		return err
+	}
+
+	if err := roleManager.SyncRoles(ctx, roleDefs); err != nil {
+		return err
+	}
+
+	revocations := repository.NewRevocationStore(log, queries)
+	go revocation.RunPurger(ctx, log, revocations, cfg.Tokens.RevocationPurgeInterval)
 
-	for alias, role := range rolesList {
-		if err := roleManager.CreateRole(ctx, alias, role.Permissions, role.Default, role.Super); err != nil {
+	tokenStates := tokenversion.NewCache(repo, cfg.Tokens.VersionCacheTTL)
+	go grants.RunSweeper(ctx, log, repo, tokenStates, cfg.Tokens.RoleGrantSweepInterval)
+
+	usecase := usecases.New(
+		log,
+		repo,
+		repo,
+		repo,
+		roleManager,
+		repo,
+		tokenGenerator,
+		keyring,
+		revocations,
+		tokenStates,
+		policies,
+		cfg.Tokens.RefreshTTL,
+	)
+
+	for alias, org := range organizationsList {
+		err := usecase.RegisterOrganization(ctx, alias, org.Name)
+		if err != nil {
 			return err
 		}
 	}
 
-	usecase := usecases.New(log, repo, tokenGenerator)
-
 	err = usecase.CreateSuperUser(ctx, cfg.Admin.Login, cfg.Admin.Password)
 	if err != nil {
 		return err
 	}
 
-	handler := handlers.New(log, usecase, tokenGenerator)
+	oauth := usecases.NewOAuth(log, usecase, repo, tokenGenerator, cfg.Tokens.AccessTTL, cfg.Tokens.CodeTTL)
 
-	server := httpserver.NewHTTPServer(ctx, cfg.HTTP.Port, handler.InitRoutes())
+	for clientID, client := range clientsList {
+		err := oauth.RegisterClient(ctx, &usecases.ClientModel{
+			ClientID:     clientID,
+			RedirectURIs: client.RedirectURIs,
+			Audiences:    client.Audiences,
+			SecretHash:   client.SecretHash,
+			Role:         client.Role,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	handler := handlers.New(
+		log,
+		usecase,
+		oauth,
+		tokenGenerator,
+		tokenGenerator,
+		tokenGenerator,
+		cfg.HTTP.PublicURL,
+		revocations,
+		tokenStates,
+	)
+
+	routes, err := handler.InitRoutes()
+	if err != nil {
+		return err
+	}
+
+	server := httpserver.NewHTTPServer(ctx, cfg.HTTP.Port, routes)
 	defer server.Shutdown(ctx)
 
 	log.Info("running http server", slog.Int("port", cfg.HTTP.Port))
@@ -87,6 +224,75 @@ func run(
 	return nil
 }
 
+// signingKey loads the key configured through the environment. It returns
+// nil when no key is configured, letting the keyring generate one.
+func signingKey(cfg *configs.JWT) (*tokenizer.Key, error) {
+	if strings.HasPrefix(cfg.Algorithm, "HS") {
+		if cfg.Signature == "" {
+			return nil, nil
+		}
+
+		return tokenizer.NewHMACKey(cfg.Algorithm, []byte(cfg.Signature))
+	}
+
+	if cfg.PrivateKeyPath == "" {
+		return nil, nil
+	}
+
+	return tokenizer.LoadKeyFiles(cfg.Algorithm, cfg.PrivateKeyPath, cfg.PublicKeyPath)
+}
+
+// loadRoles loads the permission catalog and validates the roles against it.
+func loadRoles(permissionsList configs.PermissionList, rolesList configs.RoleList) ([]roles.Definition, error) {
+	catalog := make([]roles.PermissionDefinition, 0, len(permissionsList))
+	for _, perm := range permissionsList {
+		catalog = append(catalog, roles.PermissionDefinition{
+			Name: perm.Name,
+			Bit:  perm.Bit,
+		})
+	}
+
+	if err := roles.LoadCatalog(catalog); err != nil {
+		return nil, err
+	}
+
+	defs := make([]roles.Definition, 0, len(rolesList))
+	for _, role := range rolesList {
+		defs = append(defs, roles.Definition{
+			Alias:       role.Alias,
+			Permissions: role.Permissions,
+			Inherits:    role.Inherits,
+			Default:     role.Default,
+			Super:       role.Super,
+		})
+	}
+
+	if err := roles.Validate(defs); err != nil {
+		return nil, err
+	}
+
+	return defs, nil
+}
+
+// loadPolicies builds the policy engine. The permission catalog must be
+// loaded already.
+func loadPolicies(policiesList []configs.Policy) (*policy.Engine, error) {
+	rules := make([]policy.Rule, 0, len(policiesList))
+	for _, rule := range policiesList {
+		rules = append(rules, policy.Rule{
+			Name:        rule.Name,
+			Effect:      policy.Effect(rule.Effect),
+			Actions:     rule.Actions,
+			Resources:   rule.Resources,
+			Roles:       rule.Roles,
+			Permissions: rule.Permissions,
+			Owner:       rule.Owner,
+		})
+	}
+
+	return policy.New(rules)
+}
+
 func logger(w io.Writer, env string) *slog.Logger {
 	var log *slog.Logger
 
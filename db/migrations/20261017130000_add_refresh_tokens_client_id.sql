@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS client_id VARCHAR(64) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS client_id;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE authorization_codes
+    ADD COLUMN IF NOT EXISTS nonce TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS auth_time TIMESTAMPTZ NOT NULL DEFAULT NOW();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE authorization_codes
+    DROP COLUMN IF EXISTS auth_time,
+    DROP COLUMN IF EXISTS nonce;
+-- +goose StatementEnd
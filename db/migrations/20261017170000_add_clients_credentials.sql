@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE clients
+    ADD COLUMN IF NOT EXISTS secret_hash TEXT,
+    ADD COLUMN IF NOT EXISTS role_id INTEGER REFERENCES roles(id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE clients
+    DROP COLUMN IF EXISTS role_id,
+    DROP COLUMN IF EXISTS secret_hash;
+-- +goose StatementEnd
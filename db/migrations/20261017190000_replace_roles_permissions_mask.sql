@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE roles ADD COLUMN permissions BYTEA NOT NULL DEFAULT ''::bytea;
+
+-- bit n of the mask is bit n%8 of byte n/8, so the big-endian bytes of the
+-- BIGINT are stored in reverse order.
+UPDATE roles SET permissions = decode((
+    SELECT string_agg(substr(lpad(to_hex(roles.permissions_mask), 16, '0'), 15 - 2 * i, 2), '' ORDER BY i)
+    FROM generate_series(0, 7) AS i
+), 'hex');
+
+ALTER TABLE roles DROP COLUMN permissions_mask;
+
+ALTER TABLE permissions DROP CONSTRAINT IF EXISTS permissions_bit_check;
+ALTER TABLE permissions ADD CONSTRAINT permissions_bit_check CHECK ( bit >= 0 AND bit <= 1023 );
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE roles ADD COLUMN permissions_mask BIGINT NOT NULL DEFAULT 0;
+
+-- permissions above bit 62 do not fit into the BIGINT and are lost.
+UPDATE roles SET permissions_mask = (
+    SELECT coalesce(sum((get_byte(roles.permissions, i)::BIGINT & CASE WHEN i = 7 THEN 127 ELSE 255 END) << (8 * i)), 0)::BIGINT
+    FROM generate_series(0, least(length(roles.permissions), 8) - 1) AS i
+);
+
+ALTER TABLE roles DROP COLUMN permissions;
+
+DELETE FROM permissions WHERE bit > 62;
+ALTER TABLE permissions DROP CONSTRAINT IF EXISTS permissions_bit_check;
+ALTER TABLE permissions ADD CONSTRAINT permissions_bit_check CHECK ( bit >= 0 AND bit <= 62 );
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    role_id INTEGER REFERENCES roles(id) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX IF NOT EXISTS user_roles_role_id_idx ON user_roles(role_id);
+
+INSERT INTO user_roles (user_id, role_id)
+SELECT id, role_id FROM users;
+
+ALTER TABLE users DROP COLUMN role_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN role_id INTEGER REFERENCES roles(id);
+
+-- a user keeps the role assigned first
+UPDATE users SET role_id = (
+    SELECT ur.role_id FROM user_roles ur
+    WHERE ur.user_id = users.id
+    ORDER BY ur.created_at, ur.role_id
+    LIMIT 1
+);
+
+UPDATE users SET role_id = (SELECT id FROM roles WHERE is_default = true LIMIT 1)
+WHERE role_id IS NULL;
+
+ALTER TABLE users ALTER COLUMN role_id SET NOT NULL;
+
+DROP TABLE IF EXISTS user_roles;
+-- +goose StatementEnd
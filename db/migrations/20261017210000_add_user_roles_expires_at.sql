@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_roles ADD COLUMN expires_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS user_roles_expires_at_idx ON user_roles(expires_at)
+WHERE expires_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS user_roles_expires_at_idx;
+
+DELETE FROM user_roles WHERE expires_at IS NOT NULL;
+
+ALTER TABLE user_roles DROP COLUMN expires_at;
+-- +goose StatementEnd
@@ -0,0 +1,50 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS organizations (
+    id SERIAL PRIMARY KEY,
+    alias VARCHAR(64) UNIQUE NOT NULL,
+    name VARCHAR(128) NOT NULL DEFAULT '',
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS organizations_is_default_idx ON organizations(is_default)
+WHERE is_default;
+
+INSERT INTO organizations (alias, name, is_default)
+VALUES ('default', 'Default', true);
+
+ALTER TABLE user_roles ADD COLUMN org_id INTEGER REFERENCES organizations(id) ON DELETE CASCADE;
+
+UPDATE user_roles
+SET org_id = (SELECT id FROM organizations WHERE is_default);
+
+ALTER TABLE user_roles ALTER COLUMN org_id SET NOT NULL;
+ALTER TABLE user_roles DROP CONSTRAINT user_roles_pkey;
+ALTER TABLE user_roles ADD PRIMARY KEY (org_id, user_id, role_id);
+
+CREATE INDEX IF NOT EXISTS user_roles_user_id_idx ON user_roles(user_id);
+
+ALTER TABLE refresh_tokens ADD COLUMN org_id INTEGER REFERENCES organizations(id) ON DELETE CASCADE;
+
+UPDATE refresh_tokens
+SET org_id = (SELECT id FROM organizations WHERE is_default);
+
+ALTER TABLE refresh_tokens ALTER COLUMN org_id SET NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE refresh_tokens DROP COLUMN org_id;
+
+DROP INDEX IF EXISTS user_roles_user_id_idx;
+
+DELETE FROM user_roles
+WHERE org_id <> (SELECT id FROM organizations WHERE is_default);
+
+ALTER TABLE user_roles DROP CONSTRAINT user_roles_pkey;
+ALTER TABLE user_roles ADD PRIMARY KEY (user_id, role_id);
+ALTER TABLE user_roles DROP COLUMN org_id;
+
+DROP TABLE IF EXISTS organizations;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS users_created_at_id_idx ON users(created_at, id);
+CREATE INDEX IF NOT EXISTS users_login_pattern_idx ON users(login varchar_pattern_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS users_login_pattern_idx;
+DROP INDEX IF EXISTS users_created_at_id_idx;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE signing_keys ADD COLUMN IF NOT EXISTS activates_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+UPDATE signing_keys SET activates_at = created_at;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE signing_keys DROP COLUMN IF EXISTS activates_at;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS own_permissions BYTEA NOT NULL DEFAULT ''::bytea;
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS overridden BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE roles SET own_permissions = permissions;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE roles DROP COLUMN IF EXISTS overridden;
+ALTER TABLE roles DROP COLUMN IF EXISTS own_permissions;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS user_bans (
+    org_id INTEGER REFERENCES organizations(id) ON DELETE CASCADE NOT NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (org_id, user_id)
+);
+
+INSERT INTO user_bans (org_id, user_id)
+SELECT DISTINCT ur.org_id, ur.user_id FROM user_roles ur JOIN users u
+ON u.id = ur.user_id
+WHERE u.is_banned;
+
+ALTER TABLE users DROP COLUMN IF EXISTS is_banned;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_banned BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE users SET is_banned = true
+WHERE id IN (SELECT user_id FROM user_bans);
+
+DROP TABLE IF EXISTS user_bans;
+-- +goose StatementEnd
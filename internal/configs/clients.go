@@ -0,0 +1,12 @@
+package configs
+
+type Client struct {
+	Audiences    []string `yaml:"audiences"`
+	RedirectURIs []string `yaml:"redirectURIs"`
+	SecretHash   string   `yaml:"secretHash"`
+	Role         string   `yaml:"role"`
+}
+
+func MustParseClients(path string) map[string]Client {
+	return mustParseYAML(path).Clients
+}
@@ -2,20 +2,53 @@ package configs
 
 import (
 	"log"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
 	Env  string `env:"ENV" env-default:"production"`
-	JWTSignature string `env:"JWT_SIGNATURE"`
+	JWT JWT
+	Tokens Tokens
 	HTTP HTTP
 	DB DB
 	Admin Admin
 }
 
+type JWT struct {
+	Issuer   string        `env:"JWT_ISSUER" env-default:"jwt-auth"`
+	Audience string        `env:"JWT_AUDIENCE" env-default:"jwt-auth"`
+	Leeway   time.Duration `env:"JWT_LEEWAY" env-default:"30s"`
+
+	// LegacyMaskUntil ends the transition to the perms claim: after it the
+	// int64 permissionMask claim is neither issued nor accepted. RFC 3339,
+	// unset keeps the legacy claim.
+	LegacyMaskUntil time.Time `env:"JWT_LEGACY_MASK_UNTIL"`
+
+	Algorithm      string `env:"JWT_ALGORITHM" env-default:"HS256"`
+	Signature      string `env:"JWT_SIGNATURE"`
+	PrivateKeyPath string `env:"JWT_PRIVATE_KEY_PATH"`
+	PublicKeyPath  string `env:"JWT_PUBLIC_KEY_PATH"`
+
+	RotationInterval      time.Duration `env:"JWT_ROTATION_INTERVAL" env-default:"720h"`
+	RotationGracePeriod   time.Duration `env:"JWT_ROTATION_GRACE_PERIOD" env-default:"2h"`
+	RotationCheckInterval time.Duration `env:"JWT_ROTATION_CHECK_INTERVAL" env-default:"1m"`
+}
+
+type Tokens struct {
+	AccessTTL  time.Duration `env:"ACCESS_TOKEN_TTL" env-default:"1h"`
+	RefreshTTL time.Duration `env:"REFRESH_TOKEN_TTL" env-default:"720h"`
+	CodeTTL    time.Duration `env:"AUTHORIZATION_CODE_TTL" env-default:"1m"`
+
+	RevocationPurgeInterval time.Duration `env:"REVOCATION_PURGE_INTERVAL" env-default:"10m"`
+	VersionCacheTTL         time.Duration `env:"TOKEN_VERSION_CACHE_TTL" env-default:"5s"`
+	RoleGrantSweepInterval  time.Duration `env:"ROLE_GRANT_SWEEP_INTERVAL" env-default:"1m"`
+}
+
 type HTTP struct {
-	Port int `env:"HTTP_PORT" env-default:"8080"`
+	Port      int    `env:"HTTP_PORT" env-default:"8080"`
+	PublicURL string `env:"HTTP_PUBLIC_URL" env-default:"http://localhost:8080"`
 }
 
 type Admin struct {
@@ -0,0 +1,10 @@
+package configs
+
+// Organization is a tenant users and their roles belong to, keyed by alias.
+type Organization struct {
+	Name string `yaml:"name"`
+}
+
+func MustParseOrganizations(path string) map[string]Organization {
+	return mustParseYAML(path).Organizations
+}
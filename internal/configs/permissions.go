@@ -0,0 +1,42 @@
+package configs
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Permission is a permission and the bit it has in role masks. Bits must
+// never change once roles were saved with them.
+type Permission struct {
+	Name string
+	Bit  int
+}
+
+// PermissionList keeps permissions in file order, duplicates included, like
+// RoleList.
+type PermissionList []Permission
+
+func (p *PermissionList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: permissions must be a mapping", node.Line)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var bit int
+		if err := node.Content[i+1].Decode(&bit); err != nil {
+			return err
+		}
+
+		*p = append(*p, Permission{
+			Name: node.Content[i].Value,
+			Bit:  bit,
+		})
+	}
+
+	return nil
+}
+
+func MustParsePermissions(path string) PermissionList {
+	return mustParseYAML(path).Permissions
+}
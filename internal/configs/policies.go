@@ -0,0 +1,16 @@
+package configs
+
+// Policy is an access rule of the policy engine.
+type Policy struct {
+	Name        string   `yaml:"name"`
+	Effect      string   `yaml:"effect"`
+	Actions     []string `yaml:"actions"`
+	Resources   []string `yaml:"resources"`
+	Roles       []string `yaml:"roles"`
+	Permissions []string `yaml:"permissions"`
+	Owner       bool     `yaml:"owner"`
+}
+
+func MustParsePolicies(path string) []Policy {
+	return mustParseYAML(path).Policies
+}
@@ -1,6 +1,7 @@
 package configs
 
 import (
+	"fmt"
 	"io"
 	"log"
 	"os"
@@ -9,16 +10,48 @@ import (
 )
 
 type Role struct {
+	Alias       string   `yaml:"-"`
 	Permissions []string `yaml:"permissions"`
+	Inherits    []string `yaml:"inherits"`
 	Default     bool
 	Super bool
 }
 
+// RoleList keeps roles in file order. It is decoded by hand so that an alias
+// declared twice is kept for validation instead of failing the whole file.
+type RoleList []Role
+
+func (r *RoleList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: roles must be a mapping", node.Line)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var role Role
+		if err := node.Content[i+1].Decode(&role); err != nil {
+			return err
+		}
+
+		role.Alias = node.Content[i].Value
+		*r = append(*r, role)
+	}
+
+	return nil
+}
+
 type yamlStructure struct {
-	Roles map[string]Role `yaml:"roles"`
+	Permissions   PermissionList          `yaml:"permissions"`
+	Roles         RoleList                `yaml:"roles"`
+	Clients       map[string]Client       `yaml:"clients"`
+	Policies      []Policy                `yaml:"policies"`
+	Organizations map[string]Organization `yaml:"organizations"`
+}
+
+func MustParseRoles(path string) RoleList {
+	return mustParseYAML(path).Roles
 }
 
-func MustParseRoles(path string) map[string]Role {
+func mustParseYAML(path string) yamlStructure {
 	file, err := os.Open(path)
 	if err != nil {
 		log.Fatalf("Failed to open %s: %s", path, err.Error())
@@ -36,5 +69,5 @@ func MustParseRoles(path string) map[string]Role {
 		log.Fatalf("Failed to parse yaml %s: %s", path, err.Error())
 	}
 
-	return yamlData.Roles
+	return yamlData
 }
\ No newline at end of file
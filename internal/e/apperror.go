@@ -1,9 +1,35 @@
 package e
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrAlreadyExists = errors.New("already exists")
 	ErrNotFound = errors.New("not found")
 	ErrForbiddenAction = errors.New("this action is forbidden")
+	ErrTokenExpired = errors.New("token is expired")
+	ErrTokenRevoked = errors.New("token is revoked")
+	ErrTokenReused = errors.New("token reuse detected")
+	ErrUnknownClient = errors.New("unknown client")
+	ErrUserBanned = errors.New("user is banned")
+	ErrTokenOutdated = errors.New("token is outdated")
+	ErrInvalidGrant = errors.New("invalid grant")
+	ErrInvalidRedirectURI = errors.New("invalid redirect uri")
+	ErrUnauthorizedClient = errors.New("client is not authorized for the grant")
+	ErrRoleInUse = errors.New("role is in use")
+	ErrLastRole = errors.New("user must keep at least one role")
+	ErrRoleNotGrantable = errors.New("role has permissions the caller lacks")
+	ErrUserMorePrivileged = errors.New("user has permissions the caller lacks")
+	ErrOwnRoles = errors.New("caller cannot change own roles")
+	ErrLastSuperUser = errors.New("last super user cannot be demoted")
+	ErrSuperRoleDeletion = errors.New("super role cannot be deleted")
+	ErrNotMember = errors.New("user is not a member of the organization")
+	ErrWrongPassword = errors.New("password is incorrect")
+	ErrDefaultRoleRequired = errors.New("default role can only change by making another role default")
+	ErrSuperRoleRequired = errors.New("super role can only change by making another role super")
+	ErrUserNotFound = fmt.Errorf("user %w", ErrNotFound)
+	ErrRoleNotFound = fmt.Errorf("role %w", ErrNotFound)
+	ErrRoleNotAssigned = fmt.Errorf("role assignment %w", ErrNotFound)
 )
\ No newline at end of file
@@ -9,6 +9,7 @@ import (
 type HTTPError struct {
 	Code      int       `json:"code"`
 	Message   string    `json:"message"`
+	Reason    string    `json:"reason,omitempty"`
 	Timestamp time.Time `json:"timestamp"`
 
 	err error
@@ -90,6 +91,17 @@ func Forbidden(opts ...HTTPErrorOption) *HTTPError {
 	return err
 }
 
+func Conflict(opts ...HTTPErrorOption) *HTTPError {
+	err := NewError(
+		WithStatusCode(http.StatusConflict),
+		WithMessage("conflict"),
+	)
+
+	applyOptions(err, opts...)
+
+	return err
+}
+
 type HTTPErrorOption func(e *HTTPError)
 
 func WithStatusCode(code int) HTTPErrorOption {
@@ -104,6 +116,14 @@ func WithMessage(message string) HTTPErrorOption {
 	}
 }
 
+// WithReason sets a machine readable reason telling apart errors sharing a
+// status code.
+func WithReason(reason string) HTTPErrorOption {
+	return func(e *HTTPError) {
+		e.Reason = reason
+	}
+}
+
 func WithError(err error) HTTPErrorOption {
 	return func(e *HTTPError) {
 		e.err = err
@@ -0,0 +1,56 @@
+package grants
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+)
+
+// Store takes back the roles assigned until some time. The users keep their
+// other roles, so they fall back to the ones they had before the grant.
+type Store interface {
+	ExpireRoleGrants(
+		ctx context.Context,
+		now time.Time,
+	) (userIDs []int32, err error)
+}
+
+type TokenStateInvalidator interface {
+	Invalidate(userID int32)
+}
+
+func RunSweeper(
+	ctx context.Context,
+	log *slog.Logger,
+	store Store,
+	states TokenStateInvalidator,
+	interval time.Duration,
+) {
+	const src = "grants.RunSweeper"
+	log = log.With(slog.String("src", src))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		userIDs, err := store.ExpireRoleGrants(ctx, time.Now())
+		if err != nil {
+			log.Error("failed to expire role grants", e.SlogErr(err))
+			continue
+		}
+
+		for _, userID := range userIDs {
+			states.Invalidate(userID)
+		}
+
+		log.Debug("role grants expired", slog.Int("users", len(userIDs)))
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/roles"
+)
+
+// Route is an endpoint together with the access it requires. A route is
+// either public or declares what its authenticated callers must hold, routes
+// declaring neither are refused when mounted.
+type Route struct {
+	Pattern string
+	Handler http.Handler
+	// Public routes are served without a token.
+	Public bool
+	// Require checks the caller of an authenticated route. It is one of
+	// RequirePermission, RequireAnyPermission or AnyCaller.
+	Require func(http.Handler) http.Handler
+}
+
+// mount registers routes on mux, authenticating the non-public ones with
+// auth. It registers nothing unless every route is declared.
+func mount(
+	mux *http.ServeMux,
+	auth func(http.Handler) http.Handler,
+	routes []Route,
+) error {
+	var problems []string
+	for _, route := range routes {
+		switch {
+		case route.Public && route.Require != nil:
+			problems = append(problems, fmt.Sprintf("%s: public route requires permissions", route.Pattern))
+		case !route.Public && route.Require == nil:
+			problems = append(problems, fmt.Sprintf("%s: no permissions declared", route.Pattern))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid routes: %s", strings.Join(problems, "; "))
+	}
+
+	for _, route := range routes {
+		if route.Public {
+			mux.Handle(route.Pattern, route.Handler)
+			continue
+		}
+
+		mux.Handle(route.Pattern, auth(route.Require(route.Handler)))
+	}
+
+	return nil
+}
+
+// RequirePermission lets through callers holding every one of permissions.
+// It must run after JWTAuth.
+func RequirePermission(permissions ...roles.Permission) func(http.Handler) http.Handler {
+	return requireMask(func(mask roles.Mask) bool {
+		for _, permission := range permissions {
+			if !roles.HasPermission(mask, permission) {
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+// RequireAnyPermission lets through callers holding at least one of
+// permissions. It must run after JWTAuth.
+func RequireAnyPermission(permissions ...roles.Permission) func(http.Handler) http.Handler {
+	return requireMask(func(mask roles.Mask) bool {
+		for _, permission := range permissions {
+			if roles.HasPermission(mask, permission) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// AnyCaller lets through every authenticated caller. It suits routes acting
+// on the caller itself or leaving the decision to the usecase.
+func AnyCaller(next http.Handler) http.Handler {
+	return next
+}
+
+func requireMask(allowed func(mask roles.Mask) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mask, err := PermissionMaskFromContext(r.Context())
+			if err != nil {
+				writeError(w, e.Authorization())
+				return
+			}
+
+			if !allowed(mask) {
+				writeError(w, e.Forbidden())
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -2,8 +2,12 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
+	"time"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/roles"
 )
 
 func UserIDFromContext(ctx context.Context) (int32, error) {
@@ -15,11 +19,53 @@ func UserIDFromContext(ctx context.Context) (int32, error) {
 	return int32(id), nil
 }
 
-func PermissionMaskFromContext(ctx context.Context) (int64, error) {
-	mask, err := strconv.Atoi(fmt.Sprintf("%v", ctx.Value(permissionMaskKey)))
-	if err != nil {
-		return 0, err
+func RolesFromContext(ctx context.Context) []string {
+	aliases, _ := ctx.Value(rolesKey).([]string)
+	return aliases
+}
+
+func PermissionMaskFromContext(ctx context.Context) (roles.Mask, error) {
+	mask, ok := ctx.Value(permissionMaskKey).(roles.Mask)
+	if !ok {
+		return nil, errors.New("no permissions mask in context")
 	}
 
-	return int64(mask), nil
+	return mask, nil
+}
+
+func TokenIDFromContext(ctx context.Context) (string, error) {
+	jti, ok := ctx.Value(tokenIDKey).(string)
+	if !ok || jti == "" {
+		return "", errors.New("no token id in context")
+	}
+
+	return jti, nil
+}
+
+func ExpiresAtFromContext(ctx context.Context) (time.Time, error) {
+	expiresAt, ok := ctx.Value(expiresAtKey).(time.Time)
+	if !ok {
+		return time.Time{}, errors.New("no token expiration in context")
+	}
+
+	return expiresAt, nil
+}
+
+func ClientIDFromContext(ctx context.Context) string {
+	clientID, _ := ctx.Value(clientIDKey).(string)
+	return clientID
+}
+
+// OrgIDFromContext returns the active organization of the token, zero for
+// service tokens which act in the default one.
+func OrgIDFromContext(ctx context.Context) int32 {
+	orgID, _ := ctx.Value(orgIDKey).(int32)
+	return orgID
+}
+
+// IsServiceFromContext reports whether the request is authenticated by a
+// machine client token. Such requests have no user id in context.
+func IsServiceFromContext(ctx context.Context) bool {
+	_, hasUser := ctx.Value(userIDKey).(int32)
+	return !hasUser && ClientIDFromContext(ctx) != ""
 }
\ No newline at end of file
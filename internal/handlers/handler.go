@@ -6,8 +6,11 @@ import (
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/roles"
 	"github.com/AleksandrVishniakov/jwt-auth/internal/usecases"
 )
 
@@ -15,8 +18,12 @@ type contextKey string
 
 const (
 	userIDKey         contextKey = "userID"
-	roleKey           contextKey = "role"
+	rolesKey          contextKey = "roles"
 	permissionMaskKey contextKey = "permissionMask"
+	tokenIDKey        contextKey = "tokenID"
+	expiresAtKey      contextKey = "expiresAt"
+	clientIDKey       contextKey = "clientID"
+	orgIDKey          contextKey = "orgID"
 )
 
 type Usecase interface {
@@ -30,51 +37,195 @@ type Usecase interface {
 		req *usecases.RegisterRequest,
 	) (resp *usecases.RegisterResponse, err error)
 
-	UpdateRoleById(
+	Logout(
 		ctx context.Context,
-		req *usecases.UpdateUserRoleRequest,
+		req *usecases.LogoutRequest,
+	) (err error)
+
+	AssignRole(
+		ctx context.Context,
+		req *usecases.UserRoleRequest,
+	) (err error)
+
+	UnassignRole(
+		ctx context.Context,
+		req *usecases.UserRoleRequest,
 	) (err error)
 
 	GetUserByID(
 		ctx context.Context,
 		req *usecases.GetUserByIDRequest,
 	) (*usecases.GetUserByIDResponse, error)
+
+	RotateSigningKey(
+		ctx context.Context,
+		req *usecases.RotateSigningKeyRequest,
+	) (*usecases.RotateSigningKeyResponse, error)
+
+	SetUserBanned(
+		ctx context.Context,
+		req *usecases.SetUserBannedRequest,
+	) (err error)
+
+	ListRoles(
+		ctx context.Context,
+		req *usecases.ListRolesRequest,
+	) (*usecases.ListRolesResponse, error)
+
+	GetRole(
+		ctx context.Context,
+		req *usecases.GetRoleRequest,
+	) (*usecases.RoleResponse, error)
+
+	CreateRole(
+		ctx context.Context,
+		req *usecases.SaveRoleRequest,
+	) (*usecases.RoleResponse, error)
+
+	UpdateRole(
+		ctx context.Context,
+		req *usecases.SaveRoleRequest,
+	) (*usecases.RoleResponse, error)
+
+	DeleteRole(
+		ctx context.Context,
+		req *usecases.DeleteRoleRequest,
+	) (err error)
+
+	RoleHierarchy(
+		ctx context.Context,
+		req *usecases.RoleHierarchyRequest,
+	) (*usecases.RoleHierarchyResponse, error)
+
+	ListRoleGrants(
+		ctx context.Context,
+		req *usecases.ListRoleGrantsRequest,
+	) (*usecases.ListRoleGrantsResponse, error)
+
+	CheckAccess(
+		ctx context.Context,
+		req *usecases.CheckAccessRequest,
+	) (*usecases.CheckAccessResponse, error)
+
+	ListOrganizations(
+		ctx context.Context,
+		req *usecases.ListOrganizationsRequest,
+	) (*usecases.ListOrganizationsResponse, error)
+
+	SwitchOrganization(
+		ctx context.Context,
+		req *usecases.SwitchOrganizationRequest,
+	) (*usecases.SwitchOrganizationResponse, error)
+
+	ListUsers(
+		ctx context.Context,
+		req *usecases.ListUsersRequest,
+	) (*usecases.ListUsersResponse, error)
+
+	ChangePassword(
+		ctx context.Context,
+		req *usecases.ChangePasswordRequest,
+	) (*usecases.ChangePasswordResponse, error)
 }
 
 type Handler struct {
 	log         *slog.Logger
 	usecase     Usecase
+	oauth       OAuthUsecase
 	tokenParser TokenParser
+	keys        KeySetProvider
+	discovery   DiscoveryProvider
+	publicURL   string
+	revocations RevocationChecker
+	states      TokenStateChecker
 }
 
 func New(
 	log *slog.Logger,
 	usecase Usecase,
+	oauth OAuthUsecase,
 	tokenParser TokenParser,
+	keys KeySetProvider,
+	discovery DiscoveryProvider,
+	publicURL string,
+	revocations RevocationChecker,
+	states TokenStateChecker,
 ) *Handler {
 	return &Handler{
 		log:         log,
 		usecase:     usecase,
+		oauth:       oauth,
 		tokenParser: tokenParser,
+		keys:        keys,
+		discovery:   discovery,
+		publicURL:   strings.TrimSuffix(publicURL, "/"),
+		revocations: revocations,
+		states:      states,
 	}
 }
 
-func (h *Handler) InitRoutes() http.Handler {
+// InitRoutes builds the router. It fails when an authenticated route does
+// not declare the permissions it requires.
+func (h *Handler) InitRoutes() (http.Handler, error) {
 	logger := Logger(h.log)
-	jwt := JWTAuth(h.log, h.tokenParser)
+	jwt := JWTAuth(h.log, h.tokenParser, h.revocations, h.states)
 
 	mux := http.NewServeMux()
 	v1 := http.NewServeMux()
 
-	v1.Handle("GET /ping", Error(h.Ping))
-	v1.Handle("POST /login", Error(h.Login))
-	v1.Handle("POST /register", Error(h.Register))
-	v1.Handle("PUT /change-role", jwt(Error(h.ChangeRole)))
-	v1.Handle("GET /user/{id}", jwt(Error(h.GetUser)))
+	err := mount(v1, jwt, []Route{
+		{Pattern: "GET /ping", Handler: Error(h.Ping), Public: true},
+		{Pattern: "POST /login", Handler: Error(h.Login), Public: true},
+		{Pattern: "POST /register", Handler: Error(h.Register), Public: true},
+		{Pattern: "POST /refresh", Handler: Error(h.Refresh), Public: true},
+		{Pattern: "POST /logout", Handler: Error(h.Logout), Require: AnyCaller},
+		// Users may see their own profile without CanSeeProfiles.
+		{Pattern: "GET /user/{id}", Handler: Error(h.GetUser), Require: AnyCaller},
+		{Pattern: "GET /users", Handler: Error(h.ListUsers), Require: RequirePermission(roles.CanSeeProfiles)},
+		// The current password is checked instead of a permission.
+		{Pattern: "PUT /me/password", Handler: Error(h.ChangePassword), Require: AnyCaller},
+		{Pattern: "PUT /user/{id}/ban", Handler: Error(h.BanUser), Require: RequirePermission(roles.CanBanUsers)},
+		{Pattern: "POST /user/{id}/roles", Handler: Error(h.AssignRole), Require: RequirePermission(roles.CanUpdateUserRole)},
+		{Pattern: "DELETE /user/{id}/roles/{alias}", Handler: Error(h.UnassignRole), Require: RequirePermission(roles.CanUpdateUserRole)},
+		{Pattern: "POST /keys/rotate", Handler: Error(h.RotateSigningKey), Require: RequirePermission(roles.CanManageKeys)},
+		// Clients authenticate with their credentials.
+		{Pattern: "POST /introspect", Handler: OAuthErrors(h.Introspect), Public: true},
+		{Pattern: "GET /roles", Handler: Error(h.ListRoles), Require: RequirePermission(roles.CanManageRoles)},
+		{Pattern: "GET /roles/{alias}", Handler: Error(h.GetRole), Require: RequirePermission(roles.CanManageRoles)},
+		// Roles are shared by every organization, changing them also takes
+		// the super role.
+		{Pattern: "POST /roles", Handler: Error(h.CreateRole), Require: RequirePermission(roles.CanManageRoles)},
+		{Pattern: "PUT /roles/{alias}", Handler: Error(h.UpdateRole), Require: RequirePermission(roles.CanManageRoles)},
+		{Pattern: "DELETE /roles/{alias}", Handler: Error(h.DeleteRole), Require: RequirePermission(roles.CanManageRoles)},
+		{Pattern: "GET /role-hierarchy", Handler: Error(h.RoleHierarchy), Require: RequirePermission(roles.CanManageRoles)},
+		{Pattern: "GET /role-grants", Handler: Error(h.ListRoleGrants), Require: RequirePermission(roles.CanUpdateUserRole)},
+		// The subject is described in the body, the caller only needs a token.
+		{Pattern: "POST /authorize", Handler: Error(h.CheckAccess), Require: AnyCaller},
+		{Pattern: "GET /orgs", Handler: Error(h.ListOrganizations), Require: AnyCaller},
+		{Pattern: "POST /orgs/{id}/switch", Handler: Error(h.SwitchOrganization), Require: AnyCaller},
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	mux.Handle("/v1/", http.StripPrefix("/v1", v1))
 
-	return http.StripPrefix("/api", logger(CORS(mux)))
+	root := http.NewServeMux()
+	err = mount(root, jwt, []Route{
+		{Pattern: "GET /.well-known/jwks.json", Handler: Error(h.JWKS), Public: true},
+		{Pattern: "GET /.well-known/openid-configuration", Handler: Error(h.OpenIDConfiguration), Public: true},
+		{Pattern: "GET /authorize", Handler: Error(h.AuthorizeForm), Public: true},
+		{Pattern: "POST /authorize", Handler: Error(h.Authorize), Public: true},
+		{Pattern: "POST /token", Handler: OAuthErrors(h.Token), Public: true},
+		{Pattern: "GET /userinfo", Handler: Error(h.UserInfo), Require: AnyCaller},
+		{Pattern: "POST /userinfo", Handler: Error(h.UserInfo), Require: AnyCaller},
+		{Pattern: "/api/", Handler: http.StripPrefix("/api", mux), Public: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return logger(CORS(root)), nil
 }
 
 func (h *Handler) Ping(w http.ResponseWriter, r *http.Request) error {
@@ -87,6 +238,7 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) error {
 	type loginRequset struct {
 		Login    string `json:"login"`
 		Password string `json:"password"`
+		ClientID string `json:"clientID"`
 	}
 
 	req, err := Decode[loginRequset](r.Body)
@@ -97,6 +249,7 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) error {
 	dto, err := usecases.NewLoginRequest(
 		req.Login,
 		req.Password,
+		req.ClientID,
 	)
 	if err != nil {
 		return e.BadRequest(e.WithError(err))
@@ -104,6 +257,14 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) error {
 
 	resp, err := h.usecase.Login(r.Context(), dto)
 	if err != nil {
+		if errors.Is(err, e.ErrUnknownClient) {
+			return e.BadRequest(e.WithMessage("unknown client"))
+		}
+
+		if errors.Is(err, e.ErrUserBanned) {
+			return e.Forbidden(e.WithMessage("user is banned"))
+		}
+
 		if errors.Is(err, e.ErrNotFound) {
 			return e.NotFound()
 		}
@@ -112,11 +273,13 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) error {
 	}
 
 	_ = EncodeResponse(w, struct {
-		ID    int32  `json:"id"`
-		Token string `json:"token"`
+		ID           int32  `json:"id"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refreshToken"`
 	}{
-		ID:    resp.ID,
-		Token: resp.Token,
+		ID:           resp.ID,
+		Token:        resp.Token,
+		RefreshToken: resp.RefreshToken,
 	}, http.StatusOK)
 
 	return nil
@@ -128,6 +291,7 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) error {
 	type registerRequest struct {
 		Login    string `json:"login"`
 		Password string `json:"password"`
+		ClientID string `json:"clientID"`
 	}
 
 	req, err := Decode[registerRequest](r.Body)
@@ -138,6 +302,7 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) error {
 	dto, err := usecases.NewRegisterRequest(
 		req.Login,
 		req.Password,
+		req.ClientID,
 	)
 	if err != nil {
 		return e.BadRequest(e.WithError(err))
@@ -145,6 +310,10 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) error {
 
 	resp, err := h.usecase.Register(r.Context(), dto)
 	if err != nil {
+		if errors.Is(err, e.ErrUnknownClient) {
+			return e.BadRequest(e.WithMessage("unknown client"))
+		}
+
 		if errors.Is(err, e.ErrAlreadyExists) {
 			return e.BadRequest(e.WithMessage("already exists"))
 		}
@@ -153,88 +322,356 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) error {
 	}
 
 	_ = EncodeResponse(w, struct {
-		ID    int32  `json:"id"`
-		Token string `json:"token"`
+		ID           int32  `json:"id"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refreshToken"`
 	}{
-		ID:    resp.ID,
-		Token: resp.Token,
+		ID:           resp.ID,
+		Token:        resp.Token,
+		RefreshToken: resp.RefreshToken,
 	}, http.StatusOK)
 
 	return nil
 }
 
-func (h *Handler) ChangeRole(w http.ResponseWriter, r *http.Request) error {
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) error {
 	defer r.Body.Close()
 
-	type changeRoleRequest struct {
-		UserID  int32  `json:"userID"`
-		NewRole string `json:"newRole"`
+	type refreshRequest struct {
+		RefreshToken string `json:"refreshToken"`
+		ClientID     string `json:"clientID"`
+		ClientSecret string `json:"clientSecret"`
+	}
+
+	req, err := Decode[refreshRequest](r.Body)
+	if err != nil {
+		return e.BadRequest(e.WithError(err))
 	}
 
-	req, err := Decode[changeRoleRequest](r.Body)
+	dto, err := usecases.NewRefreshRequest(req.RefreshToken, req.ClientID, req.ClientSecret)
 	if err != nil {
 		return e.BadRequest(e.WithError(err))
 	}
 
+	// Refreshing goes through OAuth so that confidential clients are
+	// authenticated whichever endpoint their tokens are presented to.
+	resp, err := h.oauth.RefreshToken(r.Context(), dto)
+	if err != nil {
+		if errors.Is(err, e.ErrUnknownClient) {
+			return e.Authorization(e.WithError(err), e.WithMessage("invalid client credentials"))
+		}
+
+		if errors.Is(err, e.ErrNotFound) ||
+			errors.Is(err, e.ErrTokenExpired) ||
+			errors.Is(err, e.ErrTokenRevoked) ||
+			errors.Is(err, e.ErrTokenReused) ||
+			errors.Is(err, e.ErrInvalidGrant) {
+			return e.Authorization(e.WithError(err))
+		}
+
+		if errors.Is(err, e.ErrUserBanned) {
+			return e.Forbidden(e.WithMessage("user is banned"))
+		}
+
+		return e.Internal(e.WithError(err))
+	}
+
+	return EncodeResponse(w, struct {
+		ID           int32  `json:"id"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refreshToken"`
+	}{
+		ID:           resp.UserID,
+		Token:        resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+	}, http.StatusOK)
+}
+
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+
+	type logoutRequest struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+
+	var req logoutRequest
+	if r.ContentLength != 0 {
+		var err error
+		req, err = Decode[logoutRequest](r.Body)
+		if err != nil {
+			return e.BadRequest(e.WithError(err))
+		}
+	}
+
+	tokenID, err := TokenIDFromContext(r.Context())
+	if err != nil {
+		return e.Authorization(e.WithError(err))
+	}
+
+	expiresAt, err := ExpiresAtFromContext(r.Context())
+	if err != nil {
+		return e.Authorization(e.WithError(err))
+	}
+
+	dto, err := usecases.NewLogoutRequest(
+		tokenID,
+		expiresAt,
+		req.RefreshToken,
+	)
+	if err != nil {
+		return e.BadRequest(e.WithError(err))
+	}
+
+	err = h.usecase.Logout(r.Context(), dto)
+	if err != nil {
+		return e.Internal(e.WithError(err))
+	}
+
+	return nil
+}
+
+func (h *Handler) AssignRole(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+
+	// A role with expiresAt is taken back at that time.
+	type assignRoleRequest struct {
+		Role      string    `json:"role"`
+		ExpiresAt time.Time `json:"expiresAt"`
+	}
+
+	req, err := Decode[assignRoleRequest](r.Body)
+	if err != nil {
+		return e.BadRequest(e.WithError(err))
+	}
+
+	dto, err := userRoleRequest(r, req.Role, req.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	err = h.usecase.AssignRole(r.Context(), dto)
+	if err != nil {
+		return userRoleError(err)
+	}
+
+	return nil
+}
+
+func (h *Handler) UnassignRole(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+
+	dto, err := userRoleRequest(r, r.PathValue("alias"), time.Time{})
+	if err != nil {
+		return err
+	}
+
+	err = h.usecase.UnassignRole(r.Context(), dto)
+	if err != nil {
+		return userRoleError(err)
+	}
+
+	return nil
+}
+
+// userRoleRequest reads the caller and the user whose roles change.
+// Service principals have no user id of their own.
+func userRoleRequest(r *http.Request, role string, expiresAt time.Time) (*usecases.UserRoleRequest, error) {
+	mask, err := PermissionMaskFromContext(r.Context())
+	if err != nil {
+		return nil, e.Authorization()
+	}
+
+	var userID int32
+	if !IsServiceFromContext(r.Context()) {
+		userID, err = UserIDFromContext(r.Context())
+		if err != nil {
+			return nil, e.Authorization()
+		}
+	}
+
+	profileID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return nil, e.BadRequest()
+	}
+
+	dto, err := usecases.NewUserRoleRequest(
+		userID,
+		OrgIDFromContext(r.Context()),
+		mask,
+		int32(profileID),
+		role,
+		expiresAt,
+	)
+	if err != nil {
+		return nil, e.BadRequest(e.WithError(err))
+	}
+
+	return dto, nil
+}
+
+// escalationReasons tell apart the refusals of the privilege escalation
+// guard.
+var escalationReasons = []struct {
+	err    error
+	reason string
+}{
+	{e.ErrOwnRoles, "own_roles"},
+	{e.ErrRoleNotGrantable, "role_not_grantable"},
+	{e.ErrUserMorePrivileged, "user_more_privileged"},
+	{e.ErrLastSuperUser, "last_super_user"},
+	{e.ErrSuperRoleDeletion, "super_role"},
+}
+
+// escalationError maps a refusal of the privilege escalation guard to a 403
+// with its reason.
+func escalationError(err error) (*e.HTTPError, bool) {
+	for _, escalation := range escalationReasons {
+		if errors.Is(err, escalation.err) {
+			return e.Forbidden(e.WithMessage(escalation.err.Error()), e.WithReason(escalation.reason)), true
+		}
+	}
+
+	return nil, false
+}
+
+func userRoleError(err error) error {
+	if errors.Is(err, e.ErrForbiddenAction) {
+		return e.Forbidden()
+	}
+
+	if httpErr, ok := escalationError(err); ok {
+		return httpErr
+	}
+
+	if errors.Is(err, e.ErrRoleNotFound) {
+		return e.NotFound(e.WithMessage("role not found"))
+	}
+
+	if errors.Is(err, e.ErrUserNotFound) {
+		return e.NotFound(e.WithMessage("user not found"))
+	}
+
+	if errors.Is(err, e.ErrRoleNotAssigned) {
+		return e.NotFound(e.WithMessage("role is not assigned to the user"))
+	}
+
+	if errors.Is(err, e.ErrLastRole) {
+		return e.Conflict(e.WithMessage("user must keep at least one role"))
+	}
+
+	return e.Internal(e.WithError(err))
+}
+
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+
 	mask, err := PermissionMaskFromContext(r.Context())
 	if err != nil {
 		return e.Authorization()
 	}
 
-	dto, err := usecases.NewUpdateUserRoleRequest(
-		req.UserID,
-		req.NewRole,
+	// Service principals have no profile of their own, so only their
+	// permissions decide.
+	var userID int32
+	if !IsServiceFromContext(r.Context()) {
+		userID, err = UserIDFromContext(r.Context())
+		if err != nil {
+			return e.Authorization()
+		}
+	}
+
+	profileID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return e.BadRequest()
+	}
+
+	dto, err := usecases.NewGetUserByIDRequest(
+		userID,
+		OrgIDFromContext(r.Context()),
+		RolesFromContext(r.Context()),
 		mask,
+		int32(profileID),
 	)
 	if err != nil {
 		return e.BadRequest(e.WithError(err))
 	}
 
-	err = h.usecase.UpdateRoleById(r.Context(), dto)
+	resp, err := h.usecase.GetUserByID(r.Context(), dto)
 	if err != nil {
 		if errors.Is(err, e.ErrForbiddenAction) {
 			return e.Forbidden()
 		}
 
+		if errors.Is(err, e.ErrNotFound) {
+			return e.NotFound()
+		}
+
 		return e.Internal(e.WithError(err))
 	}
 
-	return nil
+	return EncodeResponse(w, &struct {
+		ID    int32    `json:"id"`
+		Login string   `json:"login"`
+		Roles []string `json:"roles"`
+	}{
+		ID:    resp.ID,
+		Login: resp.Login,
+		Roles: nonNil(resp.Roles),
+	}, http.StatusOK)
 }
 
-func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) error {
+func (h *Handler) BanUser(w http.ResponseWriter, r *http.Request) error {
 	defer r.Body.Close()
 
-	mask, err := PermissionMaskFromContext(r.Context())
+	type banRequest struct {
+		Banned bool `json:"banned"`
+	}
+
+	req, err := Decode[banRequest](r.Body)
 	if err != nil {
-		return e.Authorization()
+		return e.BadRequest(e.WithError(err))
 	}
 
-	userID, err := UserIDFromContext(r.Context())
+	mask, err := PermissionMaskFromContext(r.Context())
 	if err != nil {
 		return e.Authorization()
 	}
 
+	// Service principals ban on behalf of no user, like they assign roles.
+	var userID int32
+	if !IsServiceFromContext(r.Context()) {
+		userID, err = UserIDFromContext(r.Context())
+		if err != nil {
+			return e.Authorization()
+		}
+	}
+
 	profileID, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
 		return e.BadRequest()
 	}
 
-	dto, err := usecases.NewGetUserByIDRequest(
+	dto, err := usecases.NewSetUserBannedRequest(
 		userID,
+		OrgIDFromContext(r.Context()),
 		mask,
 		int32(profileID),
+		req.Banned,
 	)
 	if err != nil {
 		return e.BadRequest(e.WithError(err))
 	}
 
-	resp, err := h.usecase.GetUserByID(r.Context(), dto)
+	err = h.usecase.SetUserBanned(r.Context(), dto)
 	if err != nil {
 		if errors.Is(err, e.ErrForbiddenAction) {
 			return e.Forbidden()
 		}
 
+		if httpErr, ok := escalationError(err); ok {
+			return httpErr
+		}
+
 		if errors.Is(err, e.ErrNotFound) {
 			return e.NotFound()
 		}
@@ -242,13 +679,34 @@ func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) error {
 		return e.Internal(e.WithError(err))
 	}
 
+	return nil
+}
+
+func (h *Handler) RotateSigningKey(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+
+	mask, err := PermissionMaskFromContext(r.Context())
+	if err != nil {
+		return e.Authorization()
+	}
+
+	dto, err := usecases.NewRotateSigningKeyRequest(mask)
+	if err != nil {
+		return e.BadRequest(e.WithError(err))
+	}
+
+	resp, err := h.usecase.RotateSigningKey(r.Context(), dto)
+	if err != nil {
+		if errors.Is(err, e.ErrForbiddenAction) {
+			return e.Forbidden()
+		}
+
+		return e.Internal(e.WithError(err))
+	}
+
 	return EncodeResponse(w, &struct {
-		ID    int32  `json:"id"`
-		Login string `json:"login"`
-		Role  string `json:"role"`
+		KeyID string `json:"kid"`
 	}{
-		ID:    resp.ID,
-		Login: resp.Login,
-		Role:  resp.Role,
+		KeyID: resp.KeyID,
 	}, http.StatusOK)
 }
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/revocation"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/roles"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/usecases"
+)
+
+// fakeUsecase records the ban requests it gets. Methods the tests do not
+// need panic through the nil embedded interface.
+type fakeUsecase struct {
+	Usecase
+	banned []*usecases.SetUserBannedRequest
+}
+
+func (f *fakeUsecase) SetUserBanned(ctx context.Context, req *usecases.SetUserBannedRequest) error {
+	f.banned = append(f.banned, req)
+	return nil
+}
+
+func TestBanUserPrincipals(t *testing.T) {
+	mask := roles.NewMask(roles.CanBanUsers)
+
+	tests := []struct {
+		name       string
+		data       TokenData
+		wantUserID int32
+	}{
+		{"user", TokenData{UserID: 1, PermissionMask: mask}, 1},
+		{"service", TokenData{ClientID: "backoffice", PermissionMask: mask}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := &fakeUsecase{}
+			h := &Handler{usecase: usecase}
+
+			auth := JWTAuth(
+				slog.New(slog.NewTextHandler(io.Discard, nil)),
+				&staticParser{data: tt.data},
+				revocation.NewMemoryStore(),
+				staticStates{},
+			)
+
+			mux := http.NewServeMux()
+			mux.Handle("PUT /user/{id}/ban", auth(Error(h.BanUser)))
+
+			req := httptest.NewRequest(http.MethodPut, "/user/2/ban", strings.NewReader(`{"banned":true}`))
+			req.Header.Set("Authorization", "Bearer token")
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+			}
+
+			if len(usecase.banned) != 1 {
+				t.Fatalf("ban requests = %d, want 1", len(usecase.banned))
+			}
+
+			got := usecase.banned[0]
+			if got.UserID != tt.wantUserID || got.ProfileID != 2 || !got.Banned {
+				t.Errorf("ban request = %+v", got)
+			}
+		})
+	}
+}
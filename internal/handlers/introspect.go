@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/roles"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/usecases"
+)
+
+type introspectionResponse struct {
+	Active      bool     `json:"active"`
+	Subject     string   `json:"sub,omitempty"`
+	ClientID    string   `json:"client_id,omitempty"`
+	Audience    []string `json:"aud,omitempty"`
+	OrgID       int32    `json:"org,omitempty"`
+	TokenType   string   `json:"token_type,omitempty"`
+	ExpiresAt   int64    `json:"exp,omitempty"`
+	TokenID     string   `json:"jti,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// Introspect implements RFC 7662 token introspection for confidential
+// clients. Any token that would be refused by JWTAuth, apart from its
+// audience, is reported as inactive without telling why.
+func (h *Handler) Introspect(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+
+	if err := r.ParseForm(); err != nil {
+		return newOAuthError(http.StatusBadRequest, "invalid_request", err)
+	}
+
+	clientID, clientSecret, err := clientCredentials(r)
+	if err != nil {
+		return newOAuthError(http.StatusBadRequest, "invalid_request", err)
+	}
+
+	dto, err := usecases.NewClientCredentialsRequest(clientID, clientSecret, "")
+	if err != nil {
+		return newOAuthError(http.StatusUnauthorized, "invalid_client", err)
+	}
+
+	err = h.oauth.AuthenticateClient(r.Context(), dto)
+	if err != nil {
+		if errors.Is(err, e.ErrUnknownClient) {
+			if _, _, ok := r.BasicAuth(); ok {
+				w.Header().Set("WWW-Authenticate", `Basic realm="introspect"`)
+			}
+
+			return newOAuthError(http.StatusUnauthorized, "invalid_client", e.ErrUnknownClient)
+		}
+
+		return newOAuthError(http.StatusInternalServerError, "server_error", err)
+	}
+
+	token := r.PostFormValue("token")
+	if token == "" {
+		return newOAuthError(http.StatusBadRequest, "invalid_request", errors.New("empty token"))
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	// Introspection serves the resource servers the token was issued to,
+	// this service need not be one of them.
+	data, err := h.tokenParser.ParseAnyAudience(token)
+	if err != nil {
+		return EncodeResponse(w, &introspectionResponse{}, http.StatusOK)
+	}
+
+	err = checkToken(r.Context(), &data, h.revocations, h.states)
+	if err != nil {
+		if errors.Is(err, e.ErrTokenRevoked) ||
+			errors.Is(err, e.ErrTokenOutdated) ||
+			errors.Is(err, e.ErrUserBanned) ||
+			errors.Is(err, e.ErrNotFound) {
+			return EncodeResponse(w, &introspectionResponse{}, http.StatusOK)
+		}
+
+		return newOAuthError(http.StatusInternalServerError, "server_error", err)
+	}
+
+	subject := strconv.Itoa(int(data.UserID))
+	if data.IsService() {
+		subject = data.ClientID
+	}
+
+	return EncodeResponse(w, &introspectionResponse{
+		Active:      true,
+		Subject:     subject,
+		ClientID:    data.ClientID,
+		Audience:    data.Audience,
+		OrgID:       data.OrgID,
+		TokenType:   "Bearer",
+		ExpiresAt:   data.ExpiresAt.Unix(),
+		TokenID:     data.TokenID,
+		Roles:       data.Roles,
+		Permissions: roles.PermissionNames(data.PermissionMask),
+	}, http.StatusOK)
+}
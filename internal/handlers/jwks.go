@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// KeySetMaxAge is how long clients may cache the key set. A rotated key must
+// be published at least this long before it signs.
+const KeySetMaxAge = 5 * time.Minute
+
+// JWK is a public key in the RFC 7517 JSON Web Key format.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+type KeySetProvider interface {
+	JWKS() JWKSet
+}
+
+func (h *Handler) JWKS(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(KeySetMaxAge.Seconds())))
+	return EncodeResponse(w, h.keys.JWKS(), http.StatusOK)
+}
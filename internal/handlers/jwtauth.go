@@ -6,23 +6,55 @@ import (
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/roles"
 )
 
 type TokenData struct {
-	UserID         int32  `json:"userID"`
-	Role           string `json:"role"`
-	PermissionMask int64  `json:"permissionMask"`
+	UserID         int32      `json:"userID"`
+	Roles          []string   `json:"roles"`
+	PermissionMask roles.Mask `json:"perms,omitempty"`
+	TokenVersion   int32      `json:"ver"`
+	ClientID       string     `json:"client_id,omitempty"`
+	OrgID          int32      `json:"org,omitempty"`
+
+	// LegacyPermissionMask is the int64 mask tokens carried before perms.
+	// It is only set while the legacy format is accepted.
+	LegacyPermissionMask int64 `json:"permissionMask,omitempty"`
+
+	TokenID   string    `json:"-"`
+	Audience  []string  `json:"-"`
+	ExpiresAt time.Time `json:"-"`
+}
+
+// IsService reports whether the token was issued to a machine client by the
+// client credentials grant. Such tokens have a client but no user.
+func (d *TokenData) IsService() bool {
+	return d.UserID == 0 && d.ClientID != ""
 }
 
 type TokenParser interface {
 	Parse(token string) (TokenData, error)
+
+	// ParseAnyAudience is Parse without the audience check.
+	ParseAnyAudience(token string) (TokenData, error)
+}
+
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+type TokenStateChecker interface {
+	TokenState(ctx context.Context, userID int32, orgID int32) (version int32, banned bool, err error)
 }
 
 func JWTAuth(
 	log *slog.Logger,
 	parser TokenParser,
+	revocations RevocationChecker,
+	states TokenStateChecker,
 ) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -30,31 +62,92 @@ func JWTAuth(
 
 			token, err := getTokenFromAuthHeader(r.Header.Get("Authorization"))
 			if err != nil {
-				httpError := e.Authorization(e.WithError(err))
-				if err = EncodeResponse(w, httpError, httpError.Code); err != nil {
-					slog.Error("encoding response error", e.SlogErr(err))
-				}
+				writeError(w, e.Authorization(e.WithError(err)))
 				return
 			}
 
 			data, err := parser.Parse(token)
 			if err != nil {
-				httpError := e.Authorization(e.WithError(err))
-				if err = EncodeResponse(w, httpError, httpError.Code); err != nil {
-					slog.Error("encoding response error", e.SlogErr(err))
+				writeError(w, e.Authorization(e.WithError(err)))
+				return
+			}
+
+			err = checkToken(ctx, &data, revocations, states)
+			if err != nil {
+				if errors.Is(err, e.ErrTokenRevoked) ||
+					errors.Is(err, e.ErrTokenOutdated) ||
+					errors.Is(err, e.ErrUserBanned) ||
+					errors.Is(err, e.ErrNotFound) {
+					writeError(w, e.Authorization(e.WithError(err)))
+					return
 				}
+
+				writeError(w, e.Internal(e.WithError(err)))
 				return
 			}
 
-			ctx = context.WithValue(ctx, userIDKey, data.UserID)
-			ctx = context.WithValue(ctx, roleKey, data.Role)
+			if !data.IsService() {
+				ctx = context.WithValue(ctx, userIDKey, data.UserID)
+			}
+			ctx = context.WithValue(ctx, clientIDKey, data.ClientID)
+			ctx = context.WithValue(ctx, orgIDKey, data.OrgID)
+			ctx = context.WithValue(ctx, rolesKey, data.Roles)
 			ctx = context.WithValue(ctx, permissionMaskKey, data.PermissionMask)
+			ctx = context.WithValue(ctx, tokenIDKey, data.TokenID)
+			ctx = context.WithValue(ctx, expiresAtKey, data.ExpiresAt)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// checkToken rejects tokens that are well formed but no longer valid: revoked
+// ones, ones issued before the latest role change of their user and ones of
+// users banned in the organization the token was issued for.
+// Service tokens have no user and are only checked for revocation.
+func checkToken(
+	ctx context.Context,
+	data *TokenData,
+	revocations RevocationChecker,
+	states TokenStateChecker,
+) error {
+	if data.TokenID != "" {
+		revoked, err := revocations.IsRevoked(ctx, data.TokenID)
+		if err != nil {
+			return err
+		}
+
+		if revoked {
+			return e.ErrTokenRevoked
+		}
+	}
+
+	if data.IsService() {
+		return nil
+	}
+
+	version, banned, err := states.TokenState(ctx, data.UserID, data.OrgID)
+	if err != nil {
+		return err
+	}
+
+	if banned {
+		return e.ErrUserBanned
+	}
+
+	if version != data.TokenVersion {
+		return e.ErrTokenOutdated
+	}
+
+	return nil
+}
+
+func writeError(w http.ResponseWriter, httpError *e.HTTPError) {
+	if err := EncodeResponse(w, httpError, httpError.Code); err != nil {
+		slog.Error("encoding response error", e.SlogErr(err))
+	}
+}
+
 func getTokenFromAuthHeader(header string) (token string, err error) {
 	const bearerAuthType = "Bearer"
 
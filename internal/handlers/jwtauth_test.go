@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/revocation"
+)
+
+// staticParser accepts any token as the one it holds.
+type staticParser struct {
+	data TokenData
+}
+
+func (p *staticParser) Parse(token string) (TokenData, error) {
+	return p.data, nil
+}
+
+func (p *staticParser) ParseAnyAudience(token string) (TokenData, error) {
+	return p.data, nil
+}
+
+type staticStates struct{}
+
+func (staticStates) TokenState(ctx context.Context, userID int32, orgID int32) (int32, bool, error) {
+	return 0, false, nil
+}
+
+func TestJWTAuthRevokedToken(t *testing.T) {
+	store := revocation.NewMemoryStore()
+	if err := store.Revoke(context.Background(), "revoked", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+
+	tests := []struct {
+		jti  string
+		want int
+	}{
+		{"revoked", http.StatusUnauthorized},
+		{"live", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.jti, func(t *testing.T) {
+			parser := &staticParser{data: TokenData{UserID: 1, TokenID: tt.jti}}
+			auth := JWTAuth(slog.New(slog.NewTextHandler(io.Discard, nil)), parser, store, staticStates{})
+
+			handler := auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer token")
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/usecases"
+)
+
+type OAuthUsecase interface {
+	ValidateAuthorization(
+		ctx context.Context,
+		req *usecases.AuthorizationRequest,
+	) (err error)
+
+	Authorize(
+		ctx context.Context,
+		req *usecases.AuthorizeRequest,
+	) (resp *usecases.AuthorizeResponse, err error)
+
+	ExchangeCode(
+		ctx context.Context,
+		req *usecases.ExchangeCodeRequest,
+	) (resp *usecases.TokenResponse, err error)
+
+	RefreshToken(
+		ctx context.Context,
+		req *usecases.RefreshRequest,
+	) (resp *usecases.TokenResponse, err error)
+
+	ClientCredentials(
+		ctx context.Context,
+		req *usecases.ClientCredentialsRequest,
+	) (resp *usecases.TokenResponse, err error)
+
+	AuthenticateClient(
+		ctx context.Context,
+		req *usecases.ClientCredentialsRequest,
+	) (err error)
+}
+
+// OAuthError is the RFC 6749 error response of the token endpoint.
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+
+	status int
+	err    error
+}
+
+func (o *OAuthError) Error() string {
+	return o.Code + ": " + o.Description
+}
+
+func (o *OAuthError) Unwrap() error {
+	return o.err
+}
+
+func newOAuthError(status int, code string, err error) *OAuthError {
+	oauthErr := &OAuthError{
+		Code:   code,
+		status: status,
+		err:    err,
+	}
+
+	if err != nil && status != http.StatusInternalServerError {
+		oauthErr.Description = err.Error()
+	}
+
+	return oauthErr
+}
+
+// OAuthErrors is the Error middleware counterpart for endpoints that must
+// answer with RFC 6749 error responses.
+func OAuthErrors(next ErrorHandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := next(w, r)
+		if err == nil {
+			return
+		}
+
+		var oauthErr *OAuthError
+		if !errors.As(err, &oauthErr) {
+			slog.Warn("got non-wrapped service error", e.SlogErr(err))
+			oauthErr = newOAuthError(http.StatusInternalServerError, "server_error", err)
+		}
+
+		slog.Debug("oauth error occured", e.SlogErr(oauthErr.Unwrap()))
+
+		w.Header().Set("Cache-Control", "no-store")
+		if err := EncodeResponse(w, oauthErr, oauthErr.status); err != nil {
+			slog.Error("encoding response error", e.SlogErr(err))
+		}
+	})
+}
+
+var authorizeTemplate = template.Must(template.New("authorize").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>Sign in</title>
+</head>
+<body>
+	{{if .Error}}<p>{{.Error}}</p>{{end}}
+	{{if .Request}}
+	<form method="post" action="authorize">
+		<input type="hidden" name="response_type" value="code">
+		<input type="hidden" name="client_id" value="{{.Request.ClientID}}">
+		<input type="hidden" name="redirect_uri" value="{{.Request.RedirectURI}}">
+		<input type="hidden" name="code_challenge" value="{{.Request.CodeChallenge}}">
+		<input type="hidden" name="code_challenge_method" value="S256">
+		<input type="hidden" name="scope" value="{{.Request.Scope}}">
+		<input type="hidden" name="state" value="{{.Request.State}}">
+		<input type="hidden" name="nonce" value="{{.Request.Nonce}}">
+		<label>Login <input name="login" autocomplete="username" required></label>
+		<label>Password <input type="password" name="password" autocomplete="current-password" required></label>
+		<button type="submit">Sign in</button>
+	</form>
+	{{end}}
+</body>
+</html>
+`))
+
+type authorizePage struct {
+	Request *usecases.AuthorizationRequest
+	Error   string
+}
+
+func renderAuthorizePage(w http.ResponseWriter, status int, page authorizePage) error {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.WriteHeader(status)
+
+	return authorizeTemplate.Execute(w, page)
+}
+
+// authorizationRequest parses and validates the client part of an
+// authorization request. Any error is rendered to the user agent instead of
+// being sent to the redirect uri, which is not trusted yet.
+func (h *Handler) authorizationRequest(r *http.Request) (*usecases.AuthorizationRequest, string, error) {
+	dto, err := usecases.NewAuthorizationRequest(
+		r.FormValue("response_type"),
+		r.FormValue("client_id"),
+		r.FormValue("redirect_uri"),
+		r.FormValue("code_challenge"),
+		r.FormValue("code_challenge_method"),
+		r.FormValue("scope"),
+		r.FormValue("state"),
+		r.FormValue("nonce"),
+	)
+	if err != nil {
+		return nil, err.Error(), err
+	}
+
+	err = h.oauth.ValidateAuthorization(r.Context(), dto)
+	if err != nil {
+		if errors.Is(err, e.ErrUnknownClient) {
+			return nil, "unknown client", err
+		}
+
+		if errors.Is(err, e.ErrInvalidRedirectURI) {
+			return nil, "redirect uri is not registered for the client", err
+		}
+
+		return nil, "internal server error", err
+	}
+
+	return dto, "", nil
+}
+
+func (h *Handler) AuthorizeForm(w http.ResponseWriter, r *http.Request) error {
+	dto, message, err := h.authorizationRequest(r)
+	if err != nil {
+		h.log.Debug("invalid authorization request", e.SlogErr(err))
+		return renderAuthorizePage(w, http.StatusBadRequest, authorizePage{Error: message})
+	}
+
+	return renderAuthorizePage(w, http.StatusOK, authorizePage{Request: dto})
+}
+
+func (h *Handler) Authorize(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+
+	dto, message, err := h.authorizationRequest(r)
+	if err != nil {
+		h.log.Debug("invalid authorization request", e.SlogErr(err))
+		return renderAuthorizePage(w, http.StatusBadRequest, authorizePage{Error: message})
+	}
+
+	req, err := usecases.NewAuthorizeRequest(
+		dto,
+		r.PostFormValue("login"),
+		r.PostFormValue("password"),
+	)
+	if err != nil {
+		return renderAuthorizePage(w, http.StatusBadRequest, authorizePage{
+			Request: dto,
+			Error:   err.Error(),
+		})
+	}
+
+	resp, err := h.oauth.Authorize(r.Context(), req)
+	if err != nil {
+		h.log.Debug("authorization failed", e.SlogErr(err))
+
+		if errors.Is(err, e.ErrUserBanned) {
+			return renderAuthorizePage(w, http.StatusForbidden, authorizePage{
+				Request: dto,
+				Error:   "user is banned",
+			})
+		}
+
+		return renderAuthorizePage(w, http.StatusUnauthorized, authorizePage{
+			Request: dto,
+			Error:   "invalid login or password",
+		})
+	}
+
+	redirect, err := url.Parse(dto.RedirectURI)
+	if err != nil {
+		return renderAuthorizePage(w, http.StatusBadRequest, authorizePage{Error: "invalid redirect uri"})
+	}
+
+	query := redirect.Query()
+	query.Set("code", resp.Code)
+	if dto.State != "" {
+		query.Set("state", dto.State)
+	}
+	redirect.RawQuery = query.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+	return nil
+}
+
+func (h *Handler) Token(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+
+	if err := r.ParseForm(); err != nil {
+		return newOAuthError(http.StatusBadRequest, "invalid_request", err)
+	}
+
+	var (
+		resp *usecases.TokenResponse
+		err  error
+	)
+
+	switch grantType := r.PostFormValue("grant_type"); grantType {
+	case "authorization_code":
+		clientID, clientSecret, credErr := clientCredentials(r)
+		if credErr != nil {
+			return newOAuthError(http.StatusBadRequest, "invalid_request", credErr)
+		}
+
+		dto, dtoErr := usecases.NewExchangeCodeRequest(
+			r.PostFormValue("code"),
+			clientID,
+			clientSecret,
+			r.PostFormValue("redirect_uri"),
+			r.PostFormValue("code_verifier"),
+		)
+		if dtoErr != nil {
+			return newOAuthError(http.StatusBadRequest, "invalid_request", dtoErr)
+		}
+
+		resp, err = h.oauth.ExchangeCode(r.Context(), dto)
+	case "refresh_token":
+		clientID, clientSecret, credErr := clientCredentials(r)
+		if credErr != nil {
+			return newOAuthError(http.StatusBadRequest, "invalid_request", credErr)
+		}
+
+		dto, dtoErr := usecases.NewRefreshRequest(
+			r.PostFormValue("refresh_token"),
+			clientID,
+			clientSecret,
+		)
+		if dtoErr != nil {
+			return newOAuthError(http.StatusBadRequest, "invalid_request", dtoErr)
+		}
+
+		resp, err = h.oauth.RefreshToken(r.Context(), dto)
+	case "client_credentials":
+		clientID, clientSecret, credErr := clientCredentials(r)
+		if credErr != nil {
+			return newOAuthError(http.StatusBadRequest, "invalid_request", credErr)
+		}
+
+		dto, dtoErr := usecases.NewClientCredentialsRequest(
+			clientID,
+			clientSecret,
+			r.PostFormValue("scope"),
+		)
+		if dtoErr != nil {
+			return newOAuthError(http.StatusBadRequest, "invalid_request", dtoErr)
+		}
+
+		resp, err = h.oauth.ClientCredentials(r.Context(), dto)
+	case "":
+		return newOAuthError(http.StatusBadRequest, "invalid_request", errors.New("missing grant type"))
+	default:
+		return newOAuthError(http.StatusBadRequest, "unsupported_grant_type", errors.New(grantType))
+	}
+
+	if err != nil {
+		if errors.Is(err, e.ErrUnknownClient) {
+			if _, _, ok := r.BasicAuth(); ok {
+				w.Header().Set("WWW-Authenticate", `Basic realm="token"`)
+			}
+
+			return newOAuthError(http.StatusUnauthorized, "invalid_client", e.ErrUnknownClient)
+		}
+
+		if errors.Is(err, e.ErrUnauthorizedClient) {
+			return newOAuthError(http.StatusBadRequest, "unauthorized_client", e.ErrUnauthorizedClient)
+		}
+
+		if errors.Is(err, e.ErrInvalidGrant) ||
+			errors.Is(err, e.ErrNotFound) ||
+			errors.Is(err, e.ErrTokenExpired) ||
+			errors.Is(err, e.ErrTokenRevoked) ||
+			errors.Is(err, e.ErrTokenReused) ||
+			errors.Is(err, e.ErrUserBanned) {
+			return newOAuthError(http.StatusBadRequest, "invalid_grant", err)
+		}
+
+		return newOAuthError(http.StatusInternalServerError, "server_error", err)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	return EncodeResponse(w, &struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int    `json:"expires_in"`
+		RefreshToken string `json:"refresh_token,omitempty"`
+		IDToken      string `json:"id_token,omitempty"`
+		Scope        string `json:"scope,omitempty"`
+	}{
+		AccessToken:  resp.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    resp.ExpiresIn,
+		RefreshToken: resp.RefreshToken,
+		IDToken:      resp.IDToken,
+		Scope:        resp.Scope,
+	}, http.StatusOK)
+}
+
+// clientCredentials reads the client authentication of a token request,
+// either from the basic auth header or from the request body.
+func clientCredentials(r *http.Request) (clientID string, clientSecret string, err error) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return r.PostFormValue("client_id"), r.PostFormValue("client_secret"), nil
+	}
+
+	if r.PostFormValue("client_secret") != "" {
+		return "", "", errors.New("multiple client authentication methods")
+	}
+
+	// RFC 6749 form-encodes the credentials before base64 encoding them.
+	clientID, err = url.QueryUnescape(username)
+	if err != nil {
+		return "", "", err
+	}
+
+	clientSecret, err = url.QueryUnescape(password)
+	if err != nil {
+		return "", "", err
+	}
+
+	if id := r.PostFormValue("client_id"); id != "" && id != clientID {
+		return "", "", errors.New("client id does not match the authenticated client")
+	}
+
+	return clientID, clientSecret, nil
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/usecases"
+)
+
+type DiscoveryProvider interface {
+	Issuer() string
+	SigningAlgorithms() []string
+}
+
+// OpenIDConfiguration serves the OpenID Connect discovery document. Endpoint
+// urls are built from the public url the service is reachable at.
+func (h *Handler) OpenIDConfiguration(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	return EncodeResponse(w, &struct {
+		Issuer                            string   `json:"issuer"`
+		AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+		TokenEndpoint                     string   `json:"token_endpoint"`
+		UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+		JWKSURI                           string   `json:"jwks_uri"`
+		ScopesSupported                   []string `json:"scopes_supported"`
+		ResponseTypesSupported            []string `json:"response_types_supported"`
+		GrantTypesSupported               []string `json:"grant_types_supported"`
+		SubjectTypesSupported             []string `json:"subject_types_supported"`
+		IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+		TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+		CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+		ClaimsSupported                   []string `json:"claims_supported"`
+	}{
+		Issuer:                            h.discovery.Issuer(),
+		AuthorizationEndpoint:             h.publicURL + "/authorize",
+		TokenEndpoint:                     h.publicURL + "/token",
+		UserInfoEndpoint:                  h.publicURL + "/userinfo",
+		JWKSURI:                           h.publicURL + "/.well-known/jwks.json",
+		ScopesSupported:                   []string{"openid"},
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code", "refresh_token", "client_credentials"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  h.discovery.SigningAlgorithms(),
+		TokenEndpointAuthMethodsSupported: []string{"none", "client_secret_basic", "client_secret_post"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported:                   []string{"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "preferred_username", "roles", "org"},
+	}, http.StatusOK)
+}
+
+func (h *Handler) UserInfo(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+
+	mask, err := PermissionMaskFromContext(r.Context())
+	if err != nil {
+		return e.Authorization()
+	}
+
+	userID, err := UserIDFromContext(r.Context())
+	if err != nil {
+		return e.Authorization()
+	}
+
+	dto, err := usecases.NewGetUserByIDRequest(
+		userID,
+		OrgIDFromContext(r.Context()),
+		RolesFromContext(r.Context()),
+		mask,
+		userID,
+	)
+	if err != nil {
+		return e.BadRequest(e.WithError(err))
+	}
+
+	resp, err := h.usecase.GetUserByID(r.Context(), dto)
+	if err != nil {
+		if errors.Is(err, e.ErrNotFound) {
+			return e.NotFound()
+		}
+
+		return e.Internal(e.WithError(err))
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	return EncodeResponse(w, &struct {
+		Subject           string   `json:"sub"`
+		PreferredUsername string   `json:"preferred_username"`
+		Roles             []string `json:"roles"`
+	}{
+		Subject:           strconv.Itoa(int(resp.ID)),
+		PreferredUsername: resp.Login,
+		Roles:             nonNil(resp.Roles),
+	}, http.StatusOK)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/usecases"
+)
+
+type organizationResponse struct {
+	ID       int32  `json:"id"`
+	Alias    string `json:"alias"`
+	Name     string `json:"name"`
+	IsActive bool   `json:"isActive"`
+}
+
+// ListOrganizations lists the organizations the caller is a member of.
+// Service principals belong to none.
+func (h *Handler) ListOrganizations(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+
+	if IsServiceFromContext(r.Context()) {
+		return e.Forbidden()
+	}
+
+	userID, err := UserIDFromContext(r.Context())
+	if err != nil {
+		return e.Authorization()
+	}
+
+	dto, err := usecases.NewListOrganizationsRequest(userID, OrgIDFromContext(r.Context()))
+	if err != nil {
+		return e.BadRequest(e.WithError(err))
+	}
+
+	resp, err := h.usecase.ListOrganizations(r.Context(), dto)
+	if err != nil {
+		return e.Internal(e.WithError(err))
+	}
+
+	organizations := make([]*organizationResponse, 0, len(resp.Organizations))
+	for _, org := range resp.Organizations {
+		organizations = append(organizations, &organizationResponse{
+			ID:       org.ID,
+			Alias:    org.Alias,
+			Name:     org.Name,
+			IsActive: org.IsActive,
+		})
+	}
+
+	return EncodeResponse(w, &struct {
+		Organizations []*organizationResponse `json:"organizations"`
+	}{
+		Organizations: organizations,
+	}, http.StatusOK)
+}
+
+// SwitchOrganization re-issues the tokens of the caller for another
+// organization it is a member of.
+func (h *Handler) SwitchOrganization(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+
+	if IsServiceFromContext(r.Context()) {
+		return e.Forbidden()
+	}
+
+	userID, err := UserIDFromContext(r.Context())
+	if err != nil {
+		return e.Authorization()
+	}
+
+	tokenID, err := TokenIDFromContext(r.Context())
+	if err != nil {
+		return e.Authorization(e.WithError(err))
+	}
+
+	expiresAt, err := ExpiresAtFromContext(r.Context())
+	if err != nil {
+		return e.Authorization(e.WithError(err))
+	}
+
+	orgID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return e.BadRequest()
+	}
+
+	dto, err := usecases.NewSwitchOrganizationRequest(
+		userID,
+		int32(orgID),
+		ClientIDFromContext(r.Context()),
+		tokenID,
+		expiresAt,
+	)
+	if err != nil {
+		return e.BadRequest(e.WithError(err))
+	}
+
+	resp, err := h.usecase.SwitchOrganization(r.Context(), dto)
+	if err != nil {
+		if errors.Is(err, e.ErrNotMember) {
+			return e.Forbidden(e.WithMessage(e.ErrNotMember.Error()))
+		}
+
+		if errors.Is(err, e.ErrUserBanned) {
+			return e.Forbidden(e.WithMessage("user is banned"))
+		}
+
+		return e.Internal(e.WithError(err))
+	}
+
+	return EncodeResponse(w, struct {
+		ID           int32  `json:"id"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refreshToken"`
+	}{
+		ID:           resp.ID,
+		Token:        resp.Token,
+		RefreshToken: resp.RefreshToken,
+	}, http.StatusOK)
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/usecases"
+)
+
+// CheckAccess answers whether a subject may do an action on a resource
+// under the policy of this service.
+func (h *Handler) CheckAccess(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+
+	type checkAccessRequest struct {
+		Subject struct {
+			UserID      int32    `json:"userID"`
+			Roles       []string `json:"roles"`
+			Permissions []string `json:"permissions"`
+		} `json:"subject"`
+		Action   string `json:"action"`
+		Resource struct {
+			Type    string `json:"type"`
+			OwnerID int32  `json:"ownerID"`
+		} `json:"resource"`
+	}
+
+	req, err := Decode[checkAccessRequest](r.Body)
+	if err != nil {
+		return e.BadRequest(e.WithError(err))
+	}
+
+	dto, err := usecases.NewCheckAccessRequest(
+		req.Subject.UserID,
+		req.Subject.Roles,
+		req.Subject.Permissions,
+		req.Action,
+		req.Resource.Type,
+		req.Resource.OwnerID,
+	)
+	if err != nil {
+		return e.BadRequest(e.WithError(err))
+	}
+
+	resp, err := h.usecase.CheckAccess(r.Context(), dto)
+	if err != nil {
+		return e.Internal(e.WithError(err))
+	}
+
+	return EncodeResponse(w, &struct {
+		Allowed bool   `json:"allowed"`
+		Rule    string `json:"rule,omitempty"`
+	}{
+		Allowed: resp.Allowed,
+		Rule:    resp.Rule,
+	}, http.StatusOK)
+}
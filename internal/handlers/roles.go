@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/usecases"
+)
+
+type roleResponse struct {
+	Alias       string   `json:"alias"`
+	Permissions []string `json:"permissions"`
+	IsDefault   bool     `json:"isDefault"`
+	IsSuper     bool     `json:"isSuper"`
+	Overridden  bool     `json:"overridden"`
+}
+
+type saveRoleRequest struct {
+	Alias       string   `json:"alias"`
+	Permissions []string `json:"permissions"`
+	IsDefault   bool     `json:"isDefault"`
+	IsSuper     bool     `json:"isSuper"`
+}
+
+type resolvedRoleResponse struct {
+	Alias                string   `json:"alias"`
+	Inherits             []string `json:"inherits"`
+	Ancestors            []string `json:"ancestors"`
+	Permissions          []string `json:"permissions"`
+	EffectivePermissions []string `json:"effectivePermissions"`
+	IsDefault            bool     `json:"isDefault"`
+	IsSuper              bool     `json:"isSuper"`
+	Overridden           bool     `json:"overridden"`
+}
+
+type roleGrantResponse struct {
+	UserID    int32     `json:"userID"`
+	Login     string    `json:"login"`
+	Role      string    `json:"role"`
+	GrantedAt time.Time `json:"grantedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func newRoleResponse(role *usecases.RoleResponse) *roleResponse {
+	return &roleResponse{
+		Alias:       role.Alias,
+		Permissions: role.Permissions,
+		IsDefault:   role.IsDefault,
+		IsSuper:     role.IsSuper,
+		Overridden:  role.Overridden,
+	}
+}
+
+func (h *Handler) ListRoles(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+
+	mask, err := PermissionMaskFromContext(r.Context())
+	if err != nil {
+		return e.Authorization()
+	}
+
+	dto, err := usecases.NewListRolesRequest(mask)
+	if err != nil {
+		return e.BadRequest(e.WithError(err))
+	}
+
+	resp, err := h.usecase.ListRoles(r.Context(), dto)
+	if err != nil {
+		if errors.Is(err, e.ErrForbiddenAction) {
+			return e.Forbidden()
+		}
+
+		return e.Internal(e.WithError(err))
+	}
+
+	roles := make([]*roleResponse, 0, len(resp.Roles))
+	for _, role := range resp.Roles {
+		roles = append(roles, newRoleResponse(role))
+	}
+
+	return EncodeResponse(w, &struct {
+		Roles []*roleResponse `json:"roles"`
+	}{
+		Roles: roles,
+	}, http.StatusOK)
+}
+
+func (h *Handler) GetRole(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+
+	mask, err := PermissionMaskFromContext(r.Context())
+	if err != nil {
+		return e.Authorization()
+	}
+
+	dto, err := usecases.NewGetRoleRequest(mask, r.PathValue("alias"))
+	if err != nil {
+		return e.BadRequest(e.WithError(err))
+	}
+
+	resp, err := h.usecase.GetRole(r.Context(), dto)
+	if err != nil {
+		if errors.Is(err, e.ErrForbiddenAction) {
+			return e.Forbidden()
+		}
+
+		if errors.Is(err, e.ErrNotFound) {
+			return e.NotFound()
+		}
+
+		return e.Internal(e.WithError(err))
+	}
+
+	return EncodeResponse(w, newRoleResponse(resp), http.StatusOK)
+}
+
+func (h *Handler) CreateRole(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+
+	req, err := Decode[saveRoleRequest](r.Body)
+	if err != nil {
+		return e.BadRequest(e.WithError(err))
+	}
+
+	mask, err := PermissionMaskFromContext(r.Context())
+	if err != nil {
+		return e.Authorization()
+	}
+
+	dto, err := usecases.NewSaveRoleRequest(
+		mask,
+		RolesFromContext(r.Context()),
+		req.Alias,
+		req.Permissions,
+		req.IsDefault,
+		req.IsSuper,
+	)
+	if err != nil {
+		return e.BadRequest(e.WithMessage(err.Error()))
+	}
+
+	resp, err := h.usecase.CreateRole(r.Context(), dto)
+	if err != nil {
+		if errors.Is(err, e.ErrForbiddenAction) {
+			return e.Forbidden()
+		}
+
+		if httpErr, ok := escalationError(err); ok {
+			return httpErr
+		}
+
+		if errors.Is(err, e.ErrAlreadyExists) {
+			return e.BadRequest(e.WithMessage("already exists"))
+		}
+
+		return e.Internal(e.WithError(err))
+	}
+
+	return EncodeResponse(w, newRoleResponse(resp), http.StatusCreated)
+}
+
+func (h *Handler) UpdateRole(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+
+	req, err := Decode[saveRoleRequest](r.Body)
+	if err != nil {
+		return e.BadRequest(e.WithError(err))
+	}
+
+	mask, err := PermissionMaskFromContext(r.Context())
+	if err != nil {
+		return e.Authorization()
+	}
+
+	dto, err := usecases.NewSaveRoleRequest(
+		mask,
+		RolesFromContext(r.Context()),
+		r.PathValue("alias"),
+		req.Permissions,
+		req.IsDefault,
+		req.IsSuper,
+	)
+	if err != nil {
+		return e.BadRequest(e.WithMessage(err.Error()))
+	}
+
+	resp, err := h.usecase.UpdateRole(r.Context(), dto)
+	if err != nil {
+		if errors.Is(err, e.ErrForbiddenAction) {
+			return e.Forbidden()
+		}
+
+		if httpErr, ok := escalationError(err); ok {
+			return httpErr
+		}
+
+		if errors.Is(err, e.ErrDefaultRoleRequired) || errors.Is(err, e.ErrSuperRoleRequired) {
+			return e.Conflict(e.WithMessage(err.Error()))
+		}
+
+		if errors.Is(err, e.ErrNotFound) {
+			return e.NotFound()
+		}
+
+		return e.Internal(e.WithError(err))
+	}
+
+	return EncodeResponse(w, newRoleResponse(resp), http.StatusOK)
+}
+
+func (h *Handler) DeleteRole(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+
+	mask, err := PermissionMaskFromContext(r.Context())
+	if err != nil {
+		return e.Authorization()
+	}
+
+	dto, err := usecases.NewDeleteRoleRequest(
+		mask,
+		RolesFromContext(r.Context()),
+		r.PathValue("alias"),
+		r.URL.Query().Get("reassignTo"),
+	)
+	if err != nil {
+		return e.BadRequest(e.WithError(err))
+	}
+
+	err = h.usecase.DeleteRole(r.Context(), dto)
+	if err != nil {
+		if errors.Is(err, e.ErrForbiddenAction) {
+			return e.Forbidden()
+		}
+
+		if httpErr, ok := escalationError(err); ok {
+			return httpErr
+		}
+
+		if errors.Is(err, e.ErrNotFound) {
+			return e.NotFound()
+		}
+
+		if errors.Is(err, e.ErrRoleInUse) {
+			return e.Conflict(e.WithMessage("role is in use, set reassignTo to move its holders"))
+		}
+
+		return e.Internal(e.WithError(err))
+	}
+
+	return nil
+}
+
+func (h *Handler) RoleHierarchy(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+
+	mask, err := PermissionMaskFromContext(r.Context())
+	if err != nil {
+		return e.Authorization()
+	}
+
+	dto, err := usecases.NewRoleHierarchyRequest(mask)
+	if err != nil {
+		return e.BadRequest(e.WithError(err))
+	}
+
+	resp, err := h.usecase.RoleHierarchy(r.Context(), dto)
+	if err != nil {
+		if errors.Is(err, e.ErrForbiddenAction) {
+			return e.Forbidden()
+		}
+
+		return e.Internal(e.WithError(err))
+	}
+
+	roles := make([]*resolvedRoleResponse, 0, len(resp.Roles))
+	for _, role := range resp.Roles {
+		roles = append(roles, &resolvedRoleResponse{
+			Alias:                role.Alias,
+			Inherits:             nonNil(role.Inherits),
+			Ancestors:            nonNil(role.Ancestors),
+			Permissions:          role.Permissions,
+			EffectivePermissions: role.EffectivePermissions,
+			IsDefault:            role.IsDefault,
+			IsSuper:              role.IsSuper,
+			Overridden:           role.Overridden,
+		})
+	}
+
+	return EncodeResponse(w, &struct {
+		Roles []*resolvedRoleResponse `json:"roles"`
+	}{
+		Roles: roles,
+	}, http.StatusOK)
+}
+
+// ListRoleGrants lists the roles assigned until some time that are still
+// in effect.
+func (h *Handler) ListRoleGrants(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+
+	mask, err := PermissionMaskFromContext(r.Context())
+	if err != nil {
+		return e.Authorization()
+	}
+
+	dto, err := usecases.NewListRoleGrantsRequest(OrgIDFromContext(r.Context()), mask)
+	if err != nil {
+		return e.BadRequest(e.WithError(err))
+	}
+
+	resp, err := h.usecase.ListRoleGrants(r.Context(), dto)
+	if err != nil {
+		if errors.Is(err, e.ErrForbiddenAction) {
+			return e.Forbidden()
+		}
+
+		return e.Internal(e.WithError(err))
+	}
+
+	grants := make([]*roleGrantResponse, 0, len(resp.Grants))
+	for _, grant := range resp.Grants {
+		grants = append(grants, &roleGrantResponse{
+			UserID:    grant.UserID,
+			Login:     grant.Login,
+			Role:      grant.Role,
+			GrantedAt: grant.GrantedAt,
+			ExpiresAt: grant.ExpiresAt,
+		})
+	}
+
+	return EncodeResponse(w, &struct {
+		Grants []*roleGrantResponse `json:"grants"`
+	}{
+		Grants: grants,
+	}, http.StatusOK)
+}
+
+// nonNil keeps empty lists encoded as [] rather than null.
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+
+	return list
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/usecases"
+)
+
+type userListItemResponse struct {
+	ID        int32     `json:"id"`
+	Login     string    `json:"login"`
+	CreatedAt time.Time `json:"createdAt"`
+	IsBanned  bool      `json:"isBanned"`
+}
+
+// ListUsers lists a page of the users of the active organization. The next
+// page is requested by passing back the cursor of the previous one along
+// with the same filters.
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+
+	mask, err := PermissionMaskFromContext(r.Context())
+	if err != nil {
+		return e.Authorization()
+	}
+
+	query := r.URL.Query()
+
+	createdFrom, err := queryTime(query, "createdFrom")
+	if err != nil {
+		return e.BadRequest(e.WithError(err))
+	}
+
+	createdTo, err := queryTime(query, "createdTo")
+	if err != nil {
+		return e.BadRequest(e.WithError(err))
+	}
+
+	var limit int
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil {
+			return e.BadRequest(e.WithError(err), e.WithMessage("invalid limit"))
+		}
+	}
+
+	dto, err := usecases.NewListUsersRequest(
+		OrgIDFromContext(r.Context()),
+		mask,
+		query.Get("role"),
+		query.Get("loginPrefix"),
+		createdFrom,
+		createdTo,
+		query.Get("sort"),
+		query.Get("cursor"),
+		limit,
+	)
+	if err != nil {
+		return e.BadRequest(e.WithError(err))
+	}
+
+	resp, err := h.usecase.ListUsers(r.Context(), dto)
+	if err != nil {
+		if errors.Is(err, e.ErrForbiddenAction) {
+			return e.Forbidden()
+		}
+
+		return e.Internal(e.WithError(err))
+	}
+
+	users := make([]*userListItemResponse, 0, len(resp.Users))
+	for _, user := range resp.Users {
+		users = append(users, &userListItemResponse{
+			ID:        user.ID,
+			Login:     user.Login,
+			CreatedAt: user.CreatedAt,
+			IsBanned:  user.IsBanned,
+		})
+	}
+
+	return EncodeResponse(w, &struct {
+		Users      []*userListItemResponse `json:"users"`
+		NextCursor string                  `json:"nextCursor,omitempty"`
+	}{
+		Users:      users,
+		NextCursor: resp.NextCursor,
+	}, http.StatusOK)
+}
+
+// ChangePassword replaces the password of the caller and ends its other
+// sessions. The caller continues with the tokens returned.
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+
+	if IsServiceFromContext(r.Context()) {
+		return e.Forbidden()
+	}
+
+	userID, err := UserIDFromContext(r.Context())
+	if err != nil {
+		return e.Authorization()
+	}
+
+	type changePasswordRequest struct {
+		CurrentPassword string `json:"currentPassword"`
+		NewPassword     string `json:"newPassword"`
+	}
+
+	req, err := Decode[changePasswordRequest](r.Body)
+	if err != nil {
+		return e.BadRequest(e.WithError(err))
+	}
+
+	dto, err := usecases.NewChangePasswordRequest(
+		userID,
+		OrgIDFromContext(r.Context()),
+		ClientIDFromContext(r.Context()),
+		req.CurrentPassword,
+		req.NewPassword,
+	)
+	if err != nil {
+		return e.BadRequest(e.WithError(err))
+	}
+
+	resp, err := h.usecase.ChangePassword(r.Context(), dto)
+	if err != nil {
+		if errors.Is(err, e.ErrWrongPassword) {
+			return e.Forbidden(e.WithMessage(e.ErrWrongPassword.Error()))
+		}
+
+		if errors.Is(err, e.ErrUserBanned) {
+			return e.Forbidden(e.WithMessage("user is banned"))
+		}
+
+		if errors.Is(err, e.ErrNotFound) {
+			return e.NotFound()
+		}
+
+		return e.Internal(e.WithError(err))
+	}
+
+	return EncodeResponse(w, struct {
+		ID           int32  `json:"id"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refreshToken"`
+	}{
+		ID:           resp.ID,
+		Token:        resp.Token,
+		RefreshToken: resp.RefreshToken,
+	}, http.StatusOK)
+}
+
+// queryTime parses an RFC 3339 query parameter, a missing one is zero.
+func queryTime(query url.Values, key string) (time.Time, error) {
+	value := query.Get(key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("invalid " + key)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,181 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/roles"
+)
+
+// Wildcard matches every action or resource type.
+const Wildcard = "*"
+
+type Effect string
+
+const (
+	Allow Effect = "allow"
+	Deny  Effect = "deny"
+)
+
+// namePattern matches valid rule names, actions and resource types.
+var namePattern = regexp.MustCompile(`^[a-z0-9_:.-]{1,64}$`)
+
+// Rule is a policy rule as declared in config. It applies to the listed
+// actions on the listed resource types when the subject meets every
+// condition: holding one of Roles, all of Permissions and, with Owner,
+// owning the resource. Conditions left empty always hold.
+type Rule struct {
+	Name        string
+	Effect      Effect
+	Actions     []string
+	Resources   []string
+	Roles       []string
+	Permissions []string
+	Owner       bool
+}
+
+// Subject is who asks for access. Service principals have no user id.
+type Subject struct {
+	UserID      int32
+	Roles       []string
+	Permissions roles.Mask
+}
+
+// Resource is what access is asked to. OwnerID is zero for resources owned
+// by nobody.
+type Resource struct {
+	Type    string
+	OwnerID int32
+}
+
+// Decision is the outcome of Authorize together with the rule deciding it,
+// empty when no rule applies.
+type Decision struct {
+	Allowed bool
+	Rule    string
+}
+
+type rule struct {
+	Rule
+	permissions roles.Mask
+}
+
+// Engine evaluates rules. Access is denied unless an allow rule applies and
+// no deny rule does.
+type Engine struct {
+	rules []rule
+}
+
+// ValidationError lists every problem found in the rules declared in config.
+type ValidationError struct {
+	Problems []string
+}
+
+func (v *ValidationError) Error() string {
+	return "invalid policies config: " + strings.Join(v.Problems, "; ")
+}
+
+// New validates rules and builds the engine. Permissions are resolved
+// against the loaded catalog.
+func New(rules []Rule) (*Engine, error) {
+	var problems []string
+
+	engine := &Engine{}
+	seen := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		if !namePattern.MatchString(r.Name) {
+			problems = append(problems, fmt.Sprintf("rule %q: invalid name", r.Name))
+		}
+
+		if seen[r.Name] {
+			problems = append(problems, fmt.Sprintf("rule %q: declared more than once", r.Name))
+		}
+		seen[r.Name] = true
+
+		if r.Effect != Allow && r.Effect != Deny {
+			problems = append(problems, fmt.Sprintf("rule %q: effect must be %q or %q", r.Name, Allow, Deny))
+		}
+
+		problems = append(problems, checkNames(r.Name, "action", r.Actions)...)
+		problems = append(problems, checkNames(r.Name, "resource", r.Resources)...)
+
+		mask, err := roles.ParsePermissions(r.Permissions)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("rule %q: %s", r.Name, err.Error()))
+		}
+
+		engine.rules = append(engine.rules, rule{
+			Rule:        r,
+			permissions: mask,
+		})
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	return engine, nil
+}
+
+func checkNames(ruleName string, kind string, names []string) []string {
+	if len(names) == 0 {
+		return []string{fmt.Sprintf("rule %q: no %ss", ruleName, kind)}
+	}
+
+	var problems []string
+	for _, name := range names {
+		if name != Wildcard && !namePattern.MatchString(name) {
+			problems = append(problems, fmt.Sprintf("rule %q: invalid %s %q", ruleName, kind, name))
+		}
+	}
+
+	return problems
+}
+
+// Authorize decides whether subject may do action on resource.
+func (e *Engine) Authorize(subject Subject, action string, resource Resource) Decision {
+	var allowedBy string
+	for _, r := range e.rules {
+		if !r.applies(subject, action, resource) {
+			continue
+		}
+
+		if r.Effect == Deny {
+			return Decision{Allowed: false, Rule: r.Name}
+		}
+
+		if allowedBy == "" {
+			allowedBy = r.Name
+		}
+	}
+
+	return Decision{Allowed: allowedBy != "", Rule: allowedBy}
+}
+
+func (r *rule) applies(subject Subject, action string, resource Resource) bool {
+	if !matches(r.Actions, action) || !matches(r.Resources, resource.Type) {
+		return false
+	}
+
+	if len(r.Roles) > 0 && !slices.ContainsFunc(subject.Roles, func(role string) bool {
+		return slices.Contains(r.Roles, role)
+	}) {
+		return false
+	}
+
+	if !subject.Permissions.Contains(r.permissions) {
+		return false
+	}
+
+	if r.Owner && (subject.UserID == 0 || subject.UserID != resource.OwnerID) {
+		return false
+	}
+
+	return true
+}
+
+func matches(patterns []string, value string) bool {
+	return slices.Contains(patterns, Wildcard) || slices.Contains(patterns, value)
+}
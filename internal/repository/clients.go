@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/repository/db"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/roles"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/usecases"
+)
+
+func (r *Repository) UpsertClient(
+	ctx context.Context,
+	client *usecases.ClientModel,
+) (err error) {
+	const src = "Repository.UpsertClient"
+	log := r.log.With(slog.String("src", src))
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to save client: %w", src, err)
+		}
+	}()
+
+	log.Debug("upserting client", slog.String("client_id", client.ClientID))
+
+	return r.queries.UpsertClient(ctx, db.UpsertClientParams{
+		ClientID:     client.ClientID,
+		RedirectUris: client.RedirectURIs,
+		Audiences:    client.Audiences,
+		SecretHash: sql.NullString{
+			String: client.SecretHash,
+			Valid:  client.SecretHash != "",
+		},
+		Alias: client.Role,
+	})
+}
+
+func (r *Repository) GetClient(
+	ctx context.Context,
+	clientID string,
+) (client *usecases.ClientModel, err error) {
+	const src = "Repository.GetClient"
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to fetch client: %w", src, err)
+		}
+	}()
+
+	entity, err := r.queries.GetClientByClientID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, e.ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return &usecases.ClientModel{
+		ClientID:       entity.ClientID,
+		RedirectURIs:   entity.RedirectUris,
+		Audiences:      entity.Audiences,
+		SecretHash:     entity.SecretHash.String,
+		Role:           entity.Alias.String,
+		PermissionMask: roles.Mask(entity.Permissions),
+	}, nil
+}
+
+func (r *Repository) CreateAuthorizationCode(
+	ctx context.Context,
+	code *usecases.AuthorizationCodeModel,
+) (err error) {
+	const src = "Repository.CreateAuthorizationCode"
+	log := r.log.With(slog.String("src", src))
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to create code: %w", src, err)
+		}
+	}()
+
+	log.Debug("creating authorization code", slog.String("client_id", code.ClientID))
+
+	return r.queries.CreateAuthorizationCode(ctx, db.CreateAuthorizationCodeParams{
+		CodeHash:      code.CodeHash,
+		ClientID:      code.ClientID,
+		UserID:        code.UserID,
+		RedirectUri:   code.RedirectURI,
+		CodeChallenge: code.CodeChallenge,
+		Scope:         code.Scope,
+		Nonce:         code.Nonce,
+		AuthTime:      code.AuthTime,
+		ExpiresAt:     code.ExpiresAt,
+	})
+}
+
+func (r *Repository) ConsumeAuthorizationCode(
+	ctx context.Context,
+	codeHash string,
+) (code *usecases.AuthorizationCodeModel, err error) {
+	const src = "Repository.ConsumeAuthorizationCode"
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to consume code: %w", src, err)
+		}
+	}()
+
+	entity, err := r.queries.ConsumeAuthorizationCode(ctx, codeHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, e.ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return &usecases.AuthorizationCodeModel{
+		CodeHash:      entity.CodeHash,
+		ClientID:      entity.ClientID,
+		UserID:        entity.UserID,
+		RedirectURI:   entity.RedirectUri,
+		CodeChallenge: entity.CodeChallenge,
+		Scope:         entity.Scope,
+		Nonce:         entity.Nonce,
+		AuthTime:      entity.AuthTime,
+		ExpiresAt:     entity.ExpiresAt,
+	}, nil
+}
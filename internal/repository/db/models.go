@@ -5,9 +5,33 @@
 package db
 
 import (
+	"database/sql"
 	"time"
 )
 
+type AuthorizationCode struct {
+	CodeHash      string
+	ClientID      string
+	UserID        int32
+	RedirectUri   string
+	CodeChallenge string
+	Scope         string
+	ExpiresAt     time.Time
+	CreatedAt     time.Time
+	Nonce         string
+	AuthTime      time.Time
+}
+
+type Client struct {
+	ID           int32
+	ClientID     string
+	RedirectUris []string
+	Audiences    []string
+	CreatedAt    time.Time
+	SecretHash   sql.NullString
+	RoleID       sql.NullInt32
+}
+
 type Metadatum struct {
 	UserID    int32
 	Name      string
@@ -17,18 +41,76 @@ type Metadatum struct {
 	UpdatedAt time.Time
 }
 
+type Organization struct {
+	ID        int32
+	Alias     string
+	Name      string
+	IsDefault bool
+	CreatedAt time.Time
+}
+
+type Permission struct {
+	Bit       int16
+	Name      string
+	CreatedAt time.Time
+}
+
+type RefreshToken struct {
+	ID        int32
+	TokenHash string
+	FamilyID  string
+	UserID    int32
+	UsedAt    sql.NullTime
+	RevokedAt sql.NullTime
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	ClientID  string
+	OrgID     int32
+}
+
+type RevokedToken struct {
+	Jti       string
+	ExpiresAt time.Time
+	RevokedAt time.Time
+}
+
 type Role struct {
-	ID              int32
-	Alias           string
-	IsDefault       bool
-	IsSuper         bool
-	PermissionsMask int64
+	ID             int32
+	Alias          string
+	IsDefault      bool
+	IsSuper        bool
+	Permissions    []byte
+	OwnPermissions []byte
+	Overridden     bool
+}
+
+type SigningKey struct {
+	Kid         string
+	Algorithm   string
+	PrivateKey  []byte
+	CreatedAt   time.Time
+	RetiredAt   sql.NullTime
+	ActivatesAt time.Time
 }
 
 type User struct {
 	ID           int32
 	Login        string
-	RoleID       int32
 	PasswordHash string
 	CreatedAt    time.Time
+	TokenVersion int32
+}
+
+type UserBan struct {
+	OrgID     int32
+	UserID    int32
+	CreatedAt time.Time
+}
+
+type UserRole struct {
+	UserID    int32
+	RoleID    int32
+	CreatedAt time.Time
+	ExpiresAt sql.NullTime
+	OrgID     int32
 }
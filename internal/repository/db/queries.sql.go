@@ -7,38 +7,338 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"time"
-)
 
-const createSuperUser = `-- name: CreateSuperUser :one
-INSERT INTO users (login, password_hash, role_id)
-VALUES (
-    $1, 
-    $2, 
-    (SELECT id FROM roles WHERE is_super = true LIMIT 1)
+	"github.com/lib/pq"
 )
+
+const assignDefaultRole = `-- name: AssignDefaultRole :exec
+INSERT INTO user_roles (org_id, user_id, role_id)
+SELECT o.id, $1::INTEGER, r.id
+FROM organizations o, roles r
+WHERE o.is_default = true AND r.is_default = true
+LIMIT 1
+`
+
+func (q *Queries) AssignDefaultRole(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, assignDefaultRole, userID)
+	return err
+}
+
+const assignSuperRole = `-- name: AssignSuperRole :exec
+INSERT INTO user_roles (org_id, user_id, role_id)
+SELECT o.id, $1::INTEGER, r.id
+FROM organizations o, roles r
+WHERE r.is_super = true
+ON CONFLICT DO NOTHING
+`
+
+func (q *Queries) AssignSuperRole(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, assignSuperRole, userID)
+	return err
+}
+
+const assignUserRole = `-- name: AssignUserRole :execrows
+INSERT INTO user_roles (org_id, user_id, role_id, expires_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (org_id, user_id, role_id)
+DO UPDATE SET
+    expires_at = EXCLUDED.expires_at
+WHERE user_roles.expires_at IS NOT NULL
+`
+
+type AssignUserRoleParams struct {
+	OrgID     int32
+	UserID    int32
+	RoleID    int32
+	ExpiresAt sql.NullTime
+}
+
+func (q *Queries) AssignUserRole(ctx context.Context, arg AssignUserRoleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, assignUserRole,
+		arg.OrgID,
+		arg.UserID,
+		arg.RoleID,
+		arg.ExpiresAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const banUser = `-- name: BanUser :exec
+INSERT INTO user_bans (org_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT (org_id, user_id) DO NOTHING
+`
+
+type BanUserParams struct {
+	OrgID  int32
+	UserID int32
+}
+
+func (q *Queries) BanUser(ctx context.Context, arg BanUserParams) error {
+	_, err := q.db.ExecContext(ctx, banUser, arg.OrgID, arg.UserID)
+	return err
+}
+
+const bumpTokenVersion = `-- name: BumpTokenVersion :execrows
+UPDATE users
+SET token_version = token_version + 1
+WHERE id = $1
+`
+
+func (q *Queries) BumpTokenVersion(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, bumpTokenVersion, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const bumpTokenVersionByRole = `-- name: BumpTokenVersionByRole :exec
+UPDATE users
+SET token_version = token_version + 1
+WHERE id IN (SELECT user_id FROM user_roles WHERE role_id = $1)
+`
+
+func (q *Queries) BumpTokenVersionByRole(ctx context.Context, roleID int32) error {
+	_, err := q.db.ExecContext(ctx, bumpTokenVersionByRole, roleID)
+	return err
+}
+
+const bumpTokenVersions = `-- name: BumpTokenVersions :exec
+UPDATE users
+SET token_version = token_version + 1
+WHERE id = ANY($1::INTEGER[])
+`
+
+func (q *Queries) BumpTokenVersions(ctx context.Context, ids []int32) error {
+	_, err := q.db.ExecContext(ctx, bumpTokenVersions, pq.Array(ids))
+	return err
+}
+
+const clearDefaultRole = `-- name: ClearDefaultRole :exec
+UPDATE roles
+SET is_default = false
+WHERE is_default = true AND id <> $1
+`
+
+func (q *Queries) ClearDefaultRole(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, clearDefaultRole, id)
+	return err
+}
+
+const clearSuperRole = `-- name: ClearSuperRole :exec
+UPDATE roles
+SET is_super = false
+WHERE is_super = true AND id <> $1
+`
+
+func (q *Queries) ClearSuperRole(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, clearSuperRole, id)
+	return err
+}
+
+const consumeAuthorizationCode = `-- name: ConsumeAuthorizationCode :one
+DELETE FROM authorization_codes
+WHERE code_hash = $1
+RETURNING code_hash, client_id, user_id, redirect_uri, code_challenge, scope, expires_at, created_at, nonce, auth_time
+`
+
+func (q *Queries) ConsumeAuthorizationCode(ctx context.Context, codeHash string) (AuthorizationCode, error) {
+	row := q.db.QueryRowContext(ctx, consumeAuthorizationCode, codeHash)
+	var i AuthorizationCode
+	err := row.Scan(
+		&i.CodeHash,
+		&i.ClientID,
+		&i.UserID,
+		&i.RedirectUri,
+		&i.CodeChallenge,
+		&i.Scope,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.Nonce,
+		&i.AuthTime,
+	)
+	return i, err
+}
+
+const countRoleHolders = `-- name: CountRoleHolders :one
+SELECT
+    (SELECT count(*) FROM user_roles WHERE user_roles.role_id = $1) AS users,
+    (SELECT count(*) FROM clients WHERE clients.role_id = $1) AS clients
+`
+
+type CountRoleHoldersRow struct {
+	Users   int64
+	Clients int64
+}
+
+func (q *Queries) CountRoleHolders(ctx context.Context, roleID int32) (CountRoleHoldersRow, error) {
+	row := q.db.QueryRowContext(ctx, countRoleHolders, roleID)
+	var i CountRoleHoldersRow
+	err := row.Scan(&i.Users, &i.Clients)
+	return i, err
+}
+
+const countSuperUsers = `-- name: CountSuperUsers :one
+SELECT count(DISTINCT ur.user_id) FROM user_roles ur JOIN roles r
+ON ur.role_id = r.id
+WHERE ur.org_id = $1 AND r.is_super = true AND ur.expires_at IS NULL
+`
+
+func (q *Queries) CountSuperUsers(ctx context.Context, orgID int32) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSuperUsers, orgID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUserRoles = `-- name: CountUserRoles :one
+SELECT count(*) FROM user_roles
+WHERE org_id = $1 AND user_id = $2 AND expires_at IS NULL
+`
+
+type CountUserRolesParams struct {
+	OrgID  int32
+	UserID int32
+}
+
+func (q *Queries) CountUserRoles(ctx context.Context, arg CountUserRolesParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUserRoles, arg.OrgID, arg.UserID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAuthorizationCode = `-- name: CreateAuthorizationCode :exec
+INSERT INTO authorization_codes (code_hash, client_id, user_id, redirect_uri, code_challenge, scope, nonce, auth_time, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+`
+
+type CreateAuthorizationCodeParams struct {
+	CodeHash      string
+	ClientID      string
+	UserID        int32
+	RedirectUri   string
+	CodeChallenge string
+	Scope         string
+	Nonce         string
+	AuthTime      time.Time
+	ExpiresAt     time.Time
+}
+
+func (q *Queries) CreateAuthorizationCode(ctx context.Context, arg CreateAuthorizationCodeParams) error {
+	_, err := q.db.ExecContext(ctx, createAuthorizationCode,
+		arg.CodeHash,
+		arg.ClientID,
+		arg.UserID,
+		arg.RedirectUri,
+		arg.CodeChallenge,
+		arg.Scope,
+		arg.Nonce,
+		arg.AuthTime,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const createPermission = `-- name: CreatePermission :exec
+INSERT INTO permissions (bit, name)
+VALUES ($1, $2)
+`
+
+type CreatePermissionParams struct {
+	Bit  int16
+	Name string
+}
+
+func (q *Queries) CreatePermission(ctx context.Context, arg CreatePermissionParams) error {
+	_, err := q.db.ExecContext(ctx, createPermission, arg.Bit, arg.Name)
+	return err
+}
+
+const createRefreshToken = `-- name: CreateRefreshToken :exec
+INSERT INTO refresh_tokens (token_hash, family_id, user_id, org_id, client_id, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreateRefreshTokenParams struct {
+	TokenHash string
+	FamilyID  string
+	UserID    int32
+	OrgID     int32
+	ClientID  string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) error {
+	_, err := q.db.ExecContext(ctx, createRefreshToken,
+		arg.TokenHash,
+		arg.FamilyID,
+		arg.UserID,
+		arg.OrgID,
+		arg.ClientID,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const createRole = `-- name: CreateRole :one
+INSERT INTO roles (alias, is_default, is_super, permissions, own_permissions, overridden)
+VALUES ($1, $2, $3, $4, $4, true)
 RETURNING id
 `
 
-type CreateSuperUserParams struct {
-	Login        string
-	PasswordHash string
+type CreateRoleParams struct {
+	Alias       string
+	IsDefault   bool
+	IsSuper     bool
+	Permissions []byte
 }
 
-func (q *Queries) CreateSuperUser(ctx context.Context, arg CreateSuperUserParams) (int32, error) {
-	row := q.db.QueryRowContext(ctx, createSuperUser, arg.Login, arg.PasswordHash)
+func (q *Queries) CreateRole(ctx context.Context, arg CreateRoleParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, createRole,
+		arg.Alias,
+		arg.IsDefault,
+		arg.IsSuper,
+		arg.Permissions,
+	)
 	var id int32
 	err := row.Scan(&id)
 	return id, err
 }
 
+const createSigningKey = `-- name: CreateSigningKey :exec
+INSERT INTO signing_keys (kid, algorithm, private_key, created_at, activates_at)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type CreateSigningKeyParams struct {
+	Kid         string
+	Algorithm   string
+	PrivateKey  []byte
+	CreatedAt   time.Time
+	ActivatesAt time.Time
+}
+
+func (q *Queries) CreateSigningKey(ctx context.Context, arg CreateSigningKeyParams) error {
+	_, err := q.db.ExecContext(ctx, createSigningKey,
+		arg.Kid,
+		arg.Algorithm,
+		arg.PrivateKey,
+		arg.CreatedAt,
+		arg.ActivatesAt,
+	)
+	return err
+}
+
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (login, password_hash, role_id)
-VALUES (
-    $1, 
-    $2, 
-    (SELECT id FROM roles WHERE is_default = true LIMIT 1)
-)
+INSERT INTO users (login, password_hash)
+VALUES ($1, $2)
 RETURNING id
 `
 
@@ -54,8 +354,145 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (int32,
 	return id, err
 }
 
+const deleteExpiredRoleGrants = `-- name: DeleteExpiredRoleGrants :many
+DELETE FROM user_roles
+WHERE expires_at <= $1
+RETURNING user_id
+`
+
+func (q *Queries) DeleteExpiredRoleGrants(ctx context.Context, expiresAt sql.NullTime) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, deleteExpiredRoleGrants, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var user_id int32
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteRole = `-- name: DeleteRole :exec
+DELETE FROM roles
+WHERE id = $1
+`
+
+func (q *Queries) DeleteRole(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, deleteRole, id)
+	return err
+}
+
+const deleteRoleAssignments = `-- name: DeleteRoleAssignments :exec
+DELETE FROM user_roles
+WHERE role_id = $1
+`
+
+func (q *Queries) DeleteRoleAssignments(ctx context.Context, roleID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteRoleAssignments, roleID)
+	return err
+}
+
+const deleteSigningKeysRetiredBefore = `-- name: DeleteSigningKeysRetiredBefore :exec
+DELETE FROM signing_keys
+WHERE retired_at < $1::TIMESTAMPTZ
+`
+
+func (q *Queries) DeleteSigningKeysRetiredBefore(ctx context.Context, before time.Time) error {
+	_, err := q.db.ExecContext(ctx, deleteSigningKeysRetiredBefore, before)
+	return err
+}
+
+const getClientByClientID = `-- name: GetClientByClientID :one
+SELECT c.id, c.client_id, c.redirect_uris, c.audiences, c.created_at, c.secret_hash, c.role_id, r.alias, r.permissions
+FROM clients c LEFT JOIN roles r
+ON c.role_id = r.id
+WHERE c.client_id = $1
+`
+
+type GetClientByClientIDRow struct {
+	ID           int32
+	ClientID     string
+	RedirectUris []string
+	Audiences    []string
+	CreatedAt    time.Time
+	SecretHash   sql.NullString
+	RoleID       sql.NullInt32
+	Alias        sql.NullString
+	Permissions  []byte
+}
+
+func (q *Queries) GetClientByClientID(ctx context.Context, clientID string) (GetClientByClientIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getClientByClientID, clientID)
+	var i GetClientByClientIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.ClientID,
+		pq.Array(&i.RedirectUris),
+		pq.Array(&i.Audiences),
+		&i.CreatedAt,
+		&i.SecretHash,
+		&i.RoleID,
+		&i.Alias,
+		&i.Permissions,
+	)
+	return i, err
+}
+
+const getDefaultOrganization = `-- name: GetDefaultOrganization :one
+SELECT id, alias, name, is_default, created_at FROM organizations
+WHERE is_default = true
+`
+
+func (q *Queries) GetDefaultOrganization(ctx context.Context) (Organization, error) {
+	row := q.db.QueryRowContext(ctx, getDefaultOrganization)
+	var i Organization
+	err := row.Scan(
+		&i.ID,
+		&i.Alias,
+		&i.Name,
+		&i.IsDefault,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getRefreshTokenForUpdate = `-- name: GetRefreshTokenForUpdate :one
+SELECT id, token_hash, family_id, user_id, used_at, revoked_at, expires_at, created_at, client_id, org_id FROM refresh_tokens
+WHERE token_hash = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetRefreshTokenForUpdate(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getRefreshTokenForUpdate, tokenHash)
+	var i RefreshToken
+	err := row.Scan(
+		&i.ID,
+		&i.TokenHash,
+		&i.FamilyID,
+		&i.UserID,
+		&i.UsedAt,
+		&i.RevokedAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.ClientID,
+		&i.OrgID,
+	)
+	return i, err
+}
+
 const getRoleByAlias = `-- name: GetRoleByAlias :one
-SELECT id, alias, is_default, is_super, permissions_mask FROM roles
+SELECT id, alias, is_default, is_super, permissions, own_permissions, overridden FROM roles
 WHERE roles.alias = $1
 `
 
@@ -67,107 +504,968 @@ func (q *Queries) GetRoleByAlias(ctx context.Context, alias string) (Role, error
 		&i.Alias,
 		&i.IsDefault,
 		&i.IsSuper,
-		&i.PermissionsMask,
+		&i.Permissions,
+		&i.OwnPermissions,
+		&i.Overridden,
 	)
 	return i, err
 }
 
-const getUserById = `-- name: GetUserById :one
-SELECT u.id, u.login, u.role_id, u.password_hash, u.created_at, r.alias, r.permissions_mask
-FROM users u JOIN roles r
-ON u.role_id = r.id
-WHERE u.id = $1
+const getRoleForUpdate = `-- name: GetRoleForUpdate :one
+SELECT id, alias, is_default, is_super, permissions, own_permissions, overridden FROM roles
+WHERE id = $1
+FOR UPDATE
 `
 
-type GetUserByIdRow struct {
-	ID              int32
-	Login           string
-	RoleID          int32
-	PasswordHash    string
-	CreatedAt       time.Time
-	Alias           string
-	PermissionsMask int64
+func (q *Queries) GetRoleForUpdate(ctx context.Context, id int32) (Role, error) {
+	row := q.db.QueryRowContext(ctx, getRoleForUpdate, id)
+	var i Role
+	err := row.Scan(
+		&i.ID,
+		&i.Alias,
+		&i.IsDefault,
+		&i.IsSuper,
+		&i.Permissions,
+		&i.OwnPermissions,
+		&i.Overridden,
+	)
+	return i, err
 }
 
-func (q *Queries) GetUserById(ctx context.Context, id int32) (GetUserByIdRow, error) {
+const getUserById = `-- name: GetUserById :one
+SELECT id, login, password_hash, created_at, token_version FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserById(ctx context.Context, id int32) (User, error) {
 	row := q.db.QueryRowContext(ctx, getUserById, id)
-	var i GetUserByIdRow
+	var i User
 	err := row.Scan(
 		&i.ID,
 		&i.Login,
-		&i.RoleID,
 		&i.PasswordHash,
 		&i.CreatedAt,
-		&i.Alias,
-		&i.PermissionsMask,
+		&i.TokenVersion,
 	)
 	return i, err
 }
 
 const getUserByLogin = `-- name: GetUserByLogin :one
-SELECT u.id, u.login, u.role_id, u.password_hash, u.created_at, r.alias, r.permissions_mask
-FROM users u JOIN roles r
-ON u.role_id = r.id
-WHERE u.login = $1
+SELECT id, login, password_hash, created_at, token_version FROM users
+WHERE login = $1
 `
 
-type GetUserByLoginRow struct {
-	ID              int32
-	Login           string
-	RoleID          int32
-	PasswordHash    string
-	CreatedAt       time.Time
-	Alias           string
-	PermissionsMask int64
-}
-
-func (q *Queries) GetUserByLogin(ctx context.Context, login string) (GetUserByLoginRow, error) {
+func (q *Queries) GetUserByLogin(ctx context.Context, login string) (User, error) {
 	row := q.db.QueryRowContext(ctx, getUserByLogin, login)
-	var i GetUserByLoginRow
+	var i User
 	err := row.Scan(
 		&i.ID,
 		&i.Login,
-		&i.RoleID,
 		&i.PasswordHash,
 		&i.CreatedAt,
-		&i.Alias,
-		&i.PermissionsMask,
+		&i.TokenVersion,
 	)
 	return i, err
 }
 
-const updateRoleById = `-- name: UpdateRoleById :exec
-UPDATE users
-SET role_id = (SELECT roles.id FROM roles WHERE roles.alias = $2 LIMIT 1)
-WHERE users.id = $1
+const getUserTokenState = `-- name: GetUserTokenState :one
+SELECT u.token_version, EXISTS (
+    SELECT 1 FROM user_bans b
+    WHERE b.org_id = $1 AND b.user_id = u.id
+) AS is_banned
+FROM users u
+WHERE u.id = $2
 `
 
-type UpdateRoleByIdParams struct {
+type GetUserTokenStateParams struct {
+	OrgID int32
 	ID    int32
+}
+
+type GetUserTokenStateRow struct {
+	TokenVersion int32
+	IsBanned     bool
+}
+
+func (q *Queries) GetUserTokenState(ctx context.Context, arg GetUserTokenStateParams) (GetUserTokenStateRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserTokenState, arg.OrgID, arg.ID)
+	var i GetUserTokenStateRow
+	err := row.Scan(&i.TokenVersion, &i.IsBanned)
+	return i, err
+}
+
+const isOrganizationMember = `-- name: IsOrganizationMember :one
+SELECT EXISTS (
+    SELECT 1 FROM user_roles
+    WHERE org_id = $1 AND user_id = $2
+        AND (expires_at IS NULL OR expires_at > NOW())
+)
+`
+
+type IsOrganizationMemberParams struct {
+	OrgID  int32
+	UserID int32
+}
+
+func (q *Queries) IsOrganizationMember(ctx context.Context, arg IsOrganizationMemberParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isOrganizationMember, arg.OrgID, arg.UserID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const isTokenRevoked = `-- name: IsTokenRevoked :one
+SELECT EXISTS (
+    SELECT 1 FROM revoked_tokens WHERE jti = $1
+)
+`
+
+func (q *Queries) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isTokenRevoked, jti)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const isUserBanned = `-- name: IsUserBanned :one
+SELECT EXISTS (
+    SELECT 1 FROM user_bans WHERE org_id = $1 AND user_id = $2
+)
+`
+
+type IsUserBannedParams struct {
+	OrgID  int32
+	UserID int32
+}
+
+func (q *Queries) IsUserBanned(ctx context.Context, arg IsUserBannedParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isUserBanned, arg.OrgID, arg.UserID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listActiveRoleGrants = `-- name: ListActiveRoleGrants :many
+SELECT ur.user_id, u.login, r.alias, ur.created_at, ur.expires_at
+FROM user_roles ur
+JOIN users u ON ur.user_id = u.id
+JOIN roles r ON ur.role_id = r.id
+WHERE ur.org_id = $1 AND ur.expires_at > $2
+ORDER BY ur.expires_at, ur.user_id
+`
+
+type ListActiveRoleGrantsParams struct {
+	OrgID     int32
+	ExpiresAt sql.NullTime
+}
+
+type ListActiveRoleGrantsRow struct {
+	UserID    int32
+	Login     string
+	Alias     string
+	CreatedAt time.Time
+	ExpiresAt sql.NullTime
+}
+
+func (q *Queries) ListActiveRoleGrants(ctx context.Context, arg ListActiveRoleGrantsParams) ([]ListActiveRoleGrantsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveRoleGrants, arg.OrgID, arg.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListActiveRoleGrantsRow
+	for rows.Next() {
+		var i ListActiveRoleGrantsRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Login,
+			&i.Alias,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBannedUsers = `-- name: ListBannedUsers :many
+SELECT user_id FROM user_bans
+WHERE org_id = $1 AND user_id = ANY($2::INTEGER[])
+`
+
+type ListBannedUsersParams struct {
+	OrgID   int32
+	UserIds []int32
+}
+
+func (q *Queries) ListBannedUsers(ctx context.Context, arg ListBannedUsersParams) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, listBannedUsers, arg.OrgID, pq.Array(arg.UserIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var user_id int32
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPermissions = `-- name: ListPermissions :many
+SELECT bit, name, created_at FROM permissions
+ORDER BY bit
+`
+
+func (q *Queries) ListPermissions(ctx context.Context) ([]Permission, error) {
+	rows, err := q.db.QueryContext(ctx, listPermissions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Permission
+	for rows.Next() {
+		var i Permission
+		if err := rows.Scan(&i.Bit, &i.Name, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRoles = `-- name: ListRoles :many
+SELECT id, alias, is_default, is_super, permissions, own_permissions, overridden FROM roles
+ORDER BY alias
+`
+
+func (q *Queries) ListRoles(ctx context.Context) ([]Role, error) {
+	rows, err := q.db.QueryContext(ctx, listRoles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Role
+	for rows.Next() {
+		var i Role
+		if err := rows.Scan(
+			&i.ID,
+			&i.Alias,
+			&i.IsDefault,
+			&i.IsSuper,
+			&i.Permissions,
+			&i.OwnPermissions,
+			&i.Overridden,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSigningKeys = `-- name: ListSigningKeys :many
+SELECT kid, algorithm, private_key, created_at, retired_at, activates_at FROM signing_keys
+ORDER BY activates_at
+`
+
+func (q *Queries) ListSigningKeys(ctx context.Context) ([]SigningKey, error) {
+	rows, err := q.db.QueryContext(ctx, listSigningKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SigningKey
+	for rows.Next() {
+		var i SigningKey
+		if err := rows.Scan(
+			&i.Kid,
+			&i.Algorithm,
+			&i.PrivateKey,
+			&i.CreatedAt,
+			&i.RetiredAt,
+			&i.ActivatesAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserOrganizations = `-- name: ListUserOrganizations :many
+SELECT DISTINCT o.id, o.alias, o.name, o.is_default, o.created_at FROM organizations o JOIN user_roles ur
+ON ur.org_id = o.id
+WHERE ur.user_id = $1
+    AND (ur.expires_at IS NULL OR ur.expires_at > NOW())
+ORDER BY o.id
+`
+
+func (q *Queries) ListUserOrganizations(ctx context.Context, userID int32) ([]Organization, error) {
+	rows, err := q.db.QueryContext(ctx, listUserOrganizations, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Organization
+	for rows.Next() {
+		var i Organization
+		if err := rows.Scan(
+			&i.ID,
+			&i.Alias,
+			&i.Name,
+			&i.IsDefault,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserRoles = `-- name: ListUserRoles :many
+SELECT r.id, r.alias, r.is_default, r.is_super, r.permissions, r.own_permissions, r.overridden, ur.expires_at FROM roles r JOIN user_roles ur
+ON ur.role_id = r.id
+WHERE ur.org_id = $1 AND ur.user_id = $2
+    AND (ur.expires_at IS NULL OR ur.expires_at > NOW())
+ORDER BY r.alias
+`
+
+type ListUserRolesParams struct {
+	OrgID  int32
+	UserID int32
+}
+
+type ListUserRolesRow struct {
+	ID             int32
+	Alias          string
+	IsDefault      bool
+	IsSuper        bool
+	Permissions    []byte
+	OwnPermissions []byte
+	Overridden     bool
+	ExpiresAt      sql.NullTime
+}
+
+func (q *Queries) ListUserRoles(ctx context.Context, arg ListUserRolesParams) ([]ListUserRolesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserRoles, arg.OrgID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserRolesRow
+	for rows.Next() {
+		var i ListUserRolesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Alias,
+			&i.IsDefault,
+			&i.IsSuper,
+			&i.Permissions,
+			&i.OwnPermissions,
+			&i.Overridden,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersByCreatedAt = `-- name: ListUsersByCreatedAt :many
+SELECT u.id, u.login, u.password_hash, u.created_at, u.token_version FROM users u
+WHERE EXISTS (
+        SELECT 1 FROM user_roles ur JOIN roles r
+        ON ur.role_id = r.id
+        WHERE ur.user_id = u.id AND ur.org_id = $1
+            AND (ur.expires_at IS NULL OR ur.expires_at > NOW())
+            AND ($2::VARCHAR IS NULL OR r.alias = $2)
+    )
+    AND ($3::VARCHAR IS NULL OR u.login LIKE $3 || '%')
+    AND ($4::TIMESTAMPTZ IS NULL OR u.created_at >= $4)
+    AND ($5::TIMESTAMPTZ IS NULL OR u.created_at < $5)
+    AND ($6::TIMESTAMPTZ IS NULL OR (u.created_at, u.id) > ($6, $7::INTEGER))
+ORDER BY u.created_at, u.id
+LIMIT $8
+`
+
+type ListUsersByCreatedAtParams struct {
+	OrgID          int32
+	Role           sql.NullString
+	LoginPrefix    sql.NullString
+	CreatedFrom    sql.NullTime
+	CreatedTo      sql.NullTime
+	AfterCreatedAt sql.NullTime
+	AfterID        int32
+	PageSize       int32
+}
+
+func (q *Queries) ListUsersByCreatedAt(ctx context.Context, arg ListUsersByCreatedAtParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersByCreatedAt,
+		arg.OrgID,
+		arg.Role,
+		arg.LoginPrefix,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Login,
+			&i.PasswordHash,
+			&i.CreatedAt,
+			&i.TokenVersion,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersByCreatedAtDesc = `-- name: ListUsersByCreatedAtDesc :many
+SELECT u.id, u.login, u.password_hash, u.created_at, u.token_version FROM users u
+WHERE EXISTS (
+        SELECT 1 FROM user_roles ur JOIN roles r
+        ON ur.role_id = r.id
+        WHERE ur.user_id = u.id AND ur.org_id = $1
+            AND (ur.expires_at IS NULL OR ur.expires_at > NOW())
+            AND ($2::VARCHAR IS NULL OR r.alias = $2)
+    )
+    AND ($3::VARCHAR IS NULL OR u.login LIKE $3 || '%')
+    AND ($4::TIMESTAMPTZ IS NULL OR u.created_at >= $4)
+    AND ($5::TIMESTAMPTZ IS NULL OR u.created_at < $5)
+    AND ($6::TIMESTAMPTZ IS NULL OR (u.created_at, u.id) < ($6, $7::INTEGER))
+ORDER BY u.created_at DESC, u.id DESC
+LIMIT $8
+`
+
+type ListUsersByCreatedAtDescParams struct {
+	OrgID          int32
+	Role           sql.NullString
+	LoginPrefix    sql.NullString
+	CreatedFrom    sql.NullTime
+	CreatedTo      sql.NullTime
+	AfterCreatedAt sql.NullTime
+	AfterID        int32
+	PageSize       int32
+}
+
+func (q *Queries) ListUsersByCreatedAtDesc(ctx context.Context, arg ListUsersByCreatedAtDescParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersByCreatedAtDesc,
+		arg.OrgID,
+		arg.Role,
+		arg.LoginPrefix,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Login,
+			&i.PasswordHash,
+			&i.CreatedAt,
+			&i.TokenVersion,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersByLogin = `-- name: ListUsersByLogin :many
+SELECT u.id, u.login, u.password_hash, u.created_at, u.token_version FROM users u
+WHERE EXISTS (
+        SELECT 1 FROM user_roles ur JOIN roles r
+        ON ur.role_id = r.id
+        WHERE ur.user_id = u.id AND ur.org_id = $1
+            AND (ur.expires_at IS NULL OR ur.expires_at > NOW())
+            AND ($2::VARCHAR IS NULL OR r.alias = $2)
+    )
+    AND ($3::VARCHAR IS NULL OR u.login LIKE $3 || '%')
+    AND ($4::TIMESTAMPTZ IS NULL OR u.created_at >= $4)
+    AND ($5::TIMESTAMPTZ IS NULL OR u.created_at < $5)
+    AND ($6::VARCHAR IS NULL OR u.login > $6)
+ORDER BY u.login
+LIMIT $7
+`
+
+type ListUsersByLoginParams struct {
+	OrgID       int32
+	Role        sql.NullString
+	LoginPrefix sql.NullString
+	CreatedFrom sql.NullTime
+	CreatedTo   sql.NullTime
+	AfterLogin  sql.NullString
+	PageSize    int32
+}
+
+func (q *Queries) ListUsersByLogin(ctx context.Context, arg ListUsersByLoginParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersByLogin,
+		arg.OrgID,
+		arg.Role,
+		arg.LoginPrefix,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.AfterLogin,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Login,
+			&i.PasswordHash,
+			&i.CreatedAt,
+			&i.TokenVersion,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersByLoginDesc = `-- name: ListUsersByLoginDesc :many
+SELECT u.id, u.login, u.password_hash, u.created_at, u.token_version FROM users u
+WHERE EXISTS (
+        SELECT 1 FROM user_roles ur JOIN roles r
+        ON ur.role_id = r.id
+        WHERE ur.user_id = u.id AND ur.org_id = $1
+            AND (ur.expires_at IS NULL OR ur.expires_at > NOW())
+            AND ($2::VARCHAR IS NULL OR r.alias = $2)
+    )
+    AND ($3::VARCHAR IS NULL OR u.login LIKE $3 || '%')
+    AND ($4::TIMESTAMPTZ IS NULL OR u.created_at >= $4)
+    AND ($5::TIMESTAMPTZ IS NULL OR u.created_at < $5)
+    AND ($6::VARCHAR IS NULL OR u.login < $6)
+ORDER BY u.login DESC
+LIMIT $7
+`
+
+type ListUsersByLoginDescParams struct {
+	OrgID       int32
+	Role        sql.NullString
+	LoginPrefix sql.NullString
+	CreatedFrom sql.NullTime
+	CreatedTo   sql.NullTime
+	AfterLogin  sql.NullString
+	PageSize    int32
+}
+
+func (q *Queries) ListUsersByLoginDesc(ctx context.Context, arg ListUsersByLoginDescParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersByLoginDesc,
+		arg.OrgID,
+		arg.Role,
+		arg.LoginPrefix,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.AfterLogin,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Login,
+			&i.PasswordHash,
+			&i.CreatedAt,
+			&i.TokenVersion,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markRefreshTokenUsed = `-- name: MarkRefreshTokenUsed :exec
+UPDATE refresh_tokens
+SET used_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) MarkRefreshTokenUsed(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, markRefreshTokenUsed, id)
+	return err
+}
+
+const purgeRevokedTokens = `-- name: PurgeRevokedTokens :execrows
+DELETE FROM revoked_tokens
+WHERE expires_at < $1
+`
+
+func (q *Queries) PurgeRevokedTokens(ctx context.Context, expiresAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeRevokedTokens, expiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const reassignClientsRole = `-- name: ReassignClientsRole :exec
+UPDATE clients
+SET role_id = $1
+WHERE role_id = $2
+`
+
+type ReassignClientsRoleParams struct {
+	ToRoleID   int32
+	FromRoleID int32
+}
+
+func (q *Queries) ReassignClientsRole(ctx context.Context, arg ReassignClientsRoleParams) error {
+	_, err := q.db.ExecContext(ctx, reassignClientsRole, arg.ToRoleID, arg.FromRoleID)
+	return err
+}
+
+const reassignUsersRole = `-- name: ReassignUsersRole :exec
+INSERT INTO user_roles (org_id, user_id, role_id, expires_at)
+SELECT org_id, user_id, $1::INTEGER, expires_at FROM user_roles
+WHERE role_id = $2
+ON CONFLICT DO NOTHING
+`
+
+type ReassignUsersRoleParams struct {
+	ToRoleID   int32
+	FromRoleID int32
+}
+
+func (q *Queries) ReassignUsersRole(ctx context.Context, arg ReassignUsersRoleParams) error {
+	_, err := q.db.ExecContext(ctx, reassignUsersRole, arg.ToRoleID, arg.FromRoleID)
+	return err
+}
+
+const retireActiveSigningKeys = `-- name: RetireActiveSigningKeys :exec
+UPDATE signing_keys
+SET retired_at = $1::TIMESTAMPTZ
+WHERE retired_at IS NULL
+`
+
+func (q *Queries) RetireActiveSigningKeys(ctx context.Context, retiredAt time.Time) error {
+	_, err := q.db.ExecContext(ctx, retireActiveSigningKeys, retiredAt)
+	return err
+}
+
+const revokeRefreshTokenFamily = `-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE family_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshTokenFamily, familyID)
+	return err
+}
+
+const revokeRefreshTokenFamilyByHash = `-- name: RevokeRefreshTokenFamilyByHash :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE family_id = (SELECT rt.family_id FROM refresh_tokens rt WHERE rt.token_hash = $1)
+    AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeRefreshTokenFamilyByHash(ctx context.Context, tokenHash string) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshTokenFamilyByHash, tokenHash)
+	return err
+}
+
+const revokeToken = `-- name: RevokeToken :exec
+INSERT INTO revoked_tokens (jti, expires_at)
+VALUES ($1, $2)
+ON CONFLICT (jti) DO NOTHING
+`
+
+type RevokeTokenParams struct {
+	Jti       string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) RevokeToken(ctx context.Context, arg RevokeTokenParams) error {
+	_, err := q.db.ExecContext(ctx, revokeToken, arg.Jti, arg.ExpiresAt)
+	return err
+}
+
+const revokeUserRefreshTokens = `-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeUserRefreshTokens(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, revokeUserRefreshTokens, userID)
+	return err
+}
+
+const setRolePermissions = `-- name: SetRolePermissions :exec
+UPDATE roles
+SET permissions = $2
+WHERE id = $1
+`
+
+type SetRolePermissionsParams struct {
+	ID          int32
+	Permissions []byte
+}
+
+func (q *Queries) SetRolePermissions(ctx context.Context, arg SetRolePermissionsParams) error {
+	_, err := q.db.ExecContext(ctx, setRolePermissions, arg.ID, arg.Permissions)
+	return err
+}
+
+const unassignUserRole = `-- name: UnassignUserRole :execrows
+DELETE FROM user_roles
+WHERE org_id = $1 AND user_id = $2 AND role_id = $3
+`
+
+type UnassignUserRoleParams struct {
+	OrgID  int32
+	UserID int32
+	RoleID int32
+}
+
+func (q *Queries) UnassignUserRole(ctx context.Context, arg UnassignUserRoleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unassignUserRole, arg.OrgID, arg.UserID, arg.RoleID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const unbanUser = `-- name: UnbanUser :exec
+DELETE FROM user_bans
+WHERE org_id = $1 AND user_id = $2
+`
+
+type UnbanUserParams struct {
+	OrgID  int32
+	UserID int32
+}
+
+func (q *Queries) UnbanUser(ctx context.Context, arg UnbanUserParams) error {
+	_, err := q.db.ExecContext(ctx, unbanUser, arg.OrgID, arg.UserID)
+	return err
+}
+
+const updateRole = `-- name: UpdateRole :exec
+UPDATE roles
+SET is_default = $2,
+    is_super = $3,
+    permissions = $4,
+    own_permissions = $4,
+    overridden = true
+WHERE id = $1
+`
+
+type UpdateRoleParams struct {
+	ID          int32
+	IsDefault   bool
+	IsSuper     bool
+	Permissions []byte
+}
+
+func (q *Queries) UpdateRole(ctx context.Context, arg UpdateRoleParams) error {
+	_, err := q.db.ExecContext(ctx, updateRole,
+		arg.ID,
+		arg.IsDefault,
+		arg.IsSuper,
+		arg.Permissions,
+	)
+	return err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :execrows
+UPDATE users
+SET password_hash = $2,
+    token_version = token_version + 1
+WHERE id = $1
+`
+
+type UpdateUserPasswordParams struct {
+	ID           int32
+	PasswordHash string
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateUserPassword, arg.ID, arg.PasswordHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upsertClient = `-- name: UpsertClient :exec
+INSERT INTO clients (client_id, redirect_uris, audiences, secret_hash, role_id)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    (SELECT id FROM roles WHERE alias = $5)
+)
+ON CONFLICT (client_id)
+DO UPDATE SET
+    redirect_uris = EXCLUDED.redirect_uris,
+    audiences = EXCLUDED.audiences,
+    secret_hash = EXCLUDED.secret_hash,
+    role_id = EXCLUDED.role_id
+`
+
+type UpsertClientParams struct {
+	ClientID     string
+	RedirectUris []string
+	Audiences    []string
+	SecretHash   sql.NullString
+	Alias        string
+}
+
+func (q *Queries) UpsertClient(ctx context.Context, arg UpsertClientParams) error {
+	_, err := q.db.ExecContext(ctx, upsertClient,
+		arg.ClientID,
+		pq.Array(arg.RedirectUris),
+		pq.Array(arg.Audiences),
+		arg.SecretHash,
+		arg.Alias,
+	)
+	return err
+}
+
+const upsertOrganization = `-- name: UpsertOrganization :exec
+INSERT INTO organizations (alias, name)
+VALUES ($1, $2)
+ON CONFLICT (alias)
+DO UPDATE SET
+    name = EXCLUDED.name
+`
+
+type UpsertOrganizationParams struct {
 	Alias string
+	Name  string
 }
 
-func (q *Queries) UpdateRoleById(ctx context.Context, arg UpdateRoleByIdParams) error {
-	_, err := q.db.ExecContext(ctx, updateRoleById, arg.ID, arg.Alias)
+func (q *Queries) UpsertOrganization(ctx context.Context, arg UpsertOrganizationParams) error {
+	_, err := q.db.ExecContext(ctx, upsertOrganization, arg.Alias, arg.Name)
 	return err
 }
 
 const upsertRole = `-- name: UpsertRole :one
-INSERT INTO roles (alias, is_default, is_super, permissions_mask)
+INSERT INTO roles (alias, is_default, is_super, own_permissions)
 VALUES ($1, $2, $3, $4)
 ON CONFLICT (alias) 
 DO UPDATE SET 
     is_default = EXCLUDED.is_default,
     is_super = EXCLUDED.is_super,
-    permissions_mask = EXCLUDED.permissions_mask
+    own_permissions = EXCLUDED.own_permissions
 RETURNING id
 `
 
 type UpsertRoleParams struct {
-	Alias           string
-	IsDefault       bool
-	IsSuper         bool
-	PermissionsMask int64
+	Alias          string
+	IsDefault      bool
+	IsSuper        bool
+	OwnPermissions []byte
 }
 
 func (q *Queries) UpsertRole(ctx context.Context, arg UpsertRoleParams) (int32, error) {
@@ -175,7 +1473,7 @@ func (q *Queries) UpsertRole(ctx context.Context, arg UpsertRoleParams) (int32,
 		arg.Alias,
 		arg.IsDefault,
 		arg.IsSuper,
-		arg.PermissionsMask,
+		arg.OwnPermissions,
 	)
 	var id int32
 	err := row.Scan(&id)
@@ -1,51 +1,380 @@
 -- name: CreateUser :one
-INSERT INTO users (login, password_hash, role_id)
-VALUES (
-    $1, 
-    $2, 
-    (SELECT id FROM roles WHERE is_default = true LIMIT 1)
-)
+INSERT INTO users (login, password_hash)
+VALUES ($1, $2)
 RETURNING id;
 
--- name: CreateSuperUser :one
-INSERT INTO users (login, password_hash, role_id)
-VALUES (
-    $1, 
-    $2, 
-    (SELECT id FROM roles WHERE is_super = true LIMIT 1)
-)
-RETURNING id;
+-- name: AssignDefaultRole :exec
+INSERT INTO user_roles (org_id, user_id, role_id)
+SELECT o.id, sqlc.arg(user_id)::INTEGER, r.id
+FROM organizations o, roles r
+WHERE o.is_default = true AND r.is_default = true
+LIMIT 1;
+
+-- name: AssignSuperRole :exec
+INSERT INTO user_roles (org_id, user_id, role_id)
+SELECT o.id, sqlc.arg(user_id)::INTEGER, r.id
+FROM organizations o, roles r
+WHERE r.is_super = true
+ON CONFLICT DO NOTHING;
 
 -- name: GetUserById :one
-SELECT u.*, r.alias, r.permissions_mask
-FROM users u JOIN roles r
-ON u.role_id = r.id
-WHERE u.id = $1;
+SELECT * FROM users
+WHERE id = $1;
 
 -- name: GetUserByLogin :one
-SELECT u.*, r.alias, r.permissions_mask
-FROM users u JOIN roles r
-ON u.role_id = r.id
-WHERE u.login = $1;
+SELECT * FROM users
+WHERE login = $1;
+
+-- name: ListUsersByLogin :many
+SELECT u.* FROM users u
+WHERE EXISTS (
+        SELECT 1 FROM user_roles ur JOIN roles r
+        ON ur.role_id = r.id
+        WHERE ur.user_id = u.id AND ur.org_id = sqlc.arg(org_id)
+            AND (ur.expires_at IS NULL OR ur.expires_at > NOW())
+            AND (sqlc.narg(role)::VARCHAR IS NULL OR r.alias = sqlc.narg(role))
+    )
+    AND (sqlc.narg(login_prefix)::VARCHAR IS NULL OR u.login LIKE sqlc.narg(login_prefix) || '%')
+    AND (sqlc.narg(created_from)::TIMESTAMPTZ IS NULL OR u.created_at >= sqlc.narg(created_from))
+    AND (sqlc.narg(created_to)::TIMESTAMPTZ IS NULL OR u.created_at < sqlc.narg(created_to))
+    AND (sqlc.narg(after_login)::VARCHAR IS NULL OR u.login > sqlc.narg(after_login))
+ORDER BY u.login
+LIMIT sqlc.arg(page_size);
 
+-- name: ListUsersByLoginDesc :many
+SELECT u.* FROM users u
+WHERE EXISTS (
+        SELECT 1 FROM user_roles ur JOIN roles r
+        ON ur.role_id = r.id
+        WHERE ur.user_id = u.id AND ur.org_id = sqlc.arg(org_id)
+            AND (ur.expires_at IS NULL OR ur.expires_at > NOW())
+            AND (sqlc.narg(role)::VARCHAR IS NULL OR r.alias = sqlc.narg(role))
+    )
+    AND (sqlc.narg(login_prefix)::VARCHAR IS NULL OR u.login LIKE sqlc.narg(login_prefix) || '%')
+    AND (sqlc.narg(created_from)::TIMESTAMPTZ IS NULL OR u.created_at >= sqlc.narg(created_from))
+    AND (sqlc.narg(created_to)::TIMESTAMPTZ IS NULL OR u.created_at < sqlc.narg(created_to))
+    AND (sqlc.narg(after_login)::VARCHAR IS NULL OR u.login < sqlc.narg(after_login))
+ORDER BY u.login DESC
+LIMIT sqlc.arg(page_size);
 
+-- name: ListUsersByCreatedAt :many
+SELECT u.* FROM users u
+WHERE EXISTS (
+        SELECT 1 FROM user_roles ur JOIN roles r
+        ON ur.role_id = r.id
+        WHERE ur.user_id = u.id AND ur.org_id = sqlc.arg(org_id)
+            AND (ur.expires_at IS NULL OR ur.expires_at > NOW())
+            AND (sqlc.narg(role)::VARCHAR IS NULL OR r.alias = sqlc.narg(role))
+    )
+    AND (sqlc.narg(login_prefix)::VARCHAR IS NULL OR u.login LIKE sqlc.narg(login_prefix) || '%')
+    AND (sqlc.narg(created_from)::TIMESTAMPTZ IS NULL OR u.created_at >= sqlc.narg(created_from))
+    AND (sqlc.narg(created_to)::TIMESTAMPTZ IS NULL OR u.created_at < sqlc.narg(created_to))
+    AND (sqlc.narg(after_created_at)::TIMESTAMPTZ IS NULL OR (u.created_at, u.id) > (sqlc.narg(after_created_at), sqlc.arg(after_id)::INTEGER))
+ORDER BY u.created_at, u.id
+LIMIT sqlc.arg(page_size);
 
+-- name: ListUsersByCreatedAtDesc :many
+SELECT u.* FROM users u
+WHERE EXISTS (
+        SELECT 1 FROM user_roles ur JOIN roles r
+        ON ur.role_id = r.id
+        WHERE ur.user_id = u.id AND ur.org_id = sqlc.arg(org_id)
+            AND (ur.expires_at IS NULL OR ur.expires_at > NOW())
+            AND (sqlc.narg(role)::VARCHAR IS NULL OR r.alias = sqlc.narg(role))
+    )
+    AND (sqlc.narg(login_prefix)::VARCHAR IS NULL OR u.login LIKE sqlc.narg(login_prefix) || '%')
+    AND (sqlc.narg(created_from)::TIMESTAMPTZ IS NULL OR u.created_at >= sqlc.narg(created_from))
+    AND (sqlc.narg(created_to)::TIMESTAMPTZ IS NULL OR u.created_at < sqlc.narg(created_to))
+    AND (sqlc.narg(after_created_at)::TIMESTAMPTZ IS NULL OR (u.created_at, u.id) < (sqlc.narg(after_created_at), sqlc.arg(after_id)::INTEGER))
+ORDER BY u.created_at DESC, u.id DESC
+LIMIT sqlc.arg(page_size);
 
--- name: UpdateRoleById :exec
+-- name: ListUserRoles :many
+SELECT r.*, ur.expires_at FROM roles r JOIN user_roles ur
+ON ur.role_id = r.id
+WHERE ur.org_id = $1 AND ur.user_id = $2
+    AND (ur.expires_at IS NULL OR ur.expires_at > NOW())
+ORDER BY r.alias;
+
+-- name: AssignUserRole :execrows
+INSERT INTO user_roles (org_id, user_id, role_id, expires_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (org_id, user_id, role_id)
+DO UPDATE SET
+    expires_at = EXCLUDED.expires_at
+WHERE user_roles.expires_at IS NOT NULL;
+
+-- name: UnassignUserRole :execrows
+DELETE FROM user_roles
+WHERE org_id = $1 AND user_id = $2 AND role_id = $3;
+
+-- name: GetRoleForUpdate :one
+SELECT * FROM roles
+WHERE id = $1
+FOR UPDATE;
+
+-- name: CountSuperUsers :one
+SELECT count(DISTINCT ur.user_id) FROM user_roles ur JOIN roles r
+ON ur.role_id = r.id
+WHERE ur.org_id = $1 AND r.is_super = true AND ur.expires_at IS NULL;
+
+-- name: CountUserRoles :one
+SELECT count(*) FROM user_roles
+WHERE org_id = $1 AND user_id = $2 AND expires_at IS NULL;
+
+-- name: BumpTokenVersion :execrows
 UPDATE users
-SET role_id = (SELECT roles.id FROM roles WHERE roles.alias = $2 LIMIT 1)
-WHERE users.id = $1;
+SET token_version = token_version + 1
+WHERE id = $1;
 
 -- name: UpsertRole :one
-INSERT INTO roles (alias, is_default, is_super, permissions_mask)
+INSERT INTO roles (alias, is_default, is_super, own_permissions)
 VALUES ($1, $2, $3, $4)
 ON CONFLICT (alias) 
 DO UPDATE SET 
     is_default = EXCLUDED.is_default,
     is_super = EXCLUDED.is_super,
-    permissions_mask = EXCLUDED.permissions_mask
+    own_permissions = EXCLUDED.own_permissions
 RETURNING id;
 
 -- name: GetRoleByAlias :one
 SELECT * FROM roles
-WHERE roles.alias = $1;
\ No newline at end of file
+WHERE roles.alias = $1;
+
+-- name: ListRoles :many
+SELECT * FROM roles
+ORDER BY alias;
+
+-- name: CreateRole :one
+INSERT INTO roles (alias, is_default, is_super, permissions, own_permissions, overridden)
+VALUES ($1, $2, $3, $4, $4, true)
+RETURNING id;
+
+-- name: UpdateRole :exec
+UPDATE roles
+SET is_default = $2,
+    is_super = $3,
+    permissions = $4,
+    own_permissions = $4,
+    overridden = true
+WHERE id = $1;
+
+-- name: SetRolePermissions :exec
+UPDATE roles
+SET permissions = $2
+WHERE id = $1;
+
+-- name: ClearDefaultRole :exec
+UPDATE roles
+SET is_default = false
+WHERE is_default = true AND id <> $1;
+
+-- name: ClearSuperRole :exec
+UPDATE roles
+SET is_super = false
+WHERE is_super = true AND id <> $1;
+
+-- name: DeleteRole :exec
+DELETE FROM roles
+WHERE id = $1;
+
+-- name: CountRoleHolders :one
+SELECT
+    (SELECT count(*) FROM user_roles WHERE user_roles.role_id = $1) AS users,
+    (SELECT count(*) FROM clients WHERE clients.role_id = $1) AS clients;
+
+-- name: ReassignUsersRole :exec
+INSERT INTO user_roles (org_id, user_id, role_id, expires_at)
+SELECT org_id, user_id, sqlc.arg(to_role_id)::INTEGER, expires_at FROM user_roles
+WHERE role_id = sqlc.arg(from_role_id)
+ON CONFLICT DO NOTHING;
+
+-- name: ListActiveRoleGrants :many
+SELECT ur.user_id, u.login, r.alias, ur.created_at, ur.expires_at
+FROM user_roles ur
+JOIN users u ON ur.user_id = u.id
+JOIN roles r ON ur.role_id = r.id
+WHERE ur.org_id = $1 AND ur.expires_at > $2
+ORDER BY ur.expires_at, ur.user_id;
+
+-- name: DeleteExpiredRoleGrants :many
+DELETE FROM user_roles
+WHERE expires_at <= $1
+RETURNING user_id;
+
+-- name: BumpTokenVersions :exec
+UPDATE users
+SET token_version = token_version + 1
+WHERE id = ANY(sqlc.arg(ids)::INTEGER[]);
+
+-- name: DeleteRoleAssignments :exec
+DELETE FROM user_roles
+WHERE role_id = $1;
+
+-- name: ReassignClientsRole :exec
+UPDATE clients
+SET role_id = sqlc.arg(to_role_id)
+WHERE role_id = sqlc.arg(from_role_id);
+
+-- name: BumpTokenVersionByRole :exec
+UPDATE users
+SET token_version = token_version + 1
+WHERE id IN (SELECT user_id FROM user_roles WHERE role_id = $1);
+
+-- name: GetDefaultOrganization :one
+SELECT * FROM organizations
+WHERE is_default = true;
+
+-- name: ListUserOrganizations :many
+SELECT DISTINCT o.* FROM organizations o JOIN user_roles ur
+ON ur.org_id = o.id
+WHERE ur.user_id = $1
+    AND (ur.expires_at IS NULL OR ur.expires_at > NOW())
+ORDER BY o.id;
+
+-- name: UpsertOrganization :exec
+INSERT INTO organizations (alias, name)
+VALUES ($1, $2)
+ON CONFLICT (alias)
+DO UPDATE SET
+    name = EXCLUDED.name;
+
+-- name: CreateRefreshToken :exec
+INSERT INTO refresh_tokens (token_hash, family_id, user_id, org_id, client_id, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: GetRefreshTokenForUpdate :one
+SELECT * FROM refresh_tokens
+WHERE token_hash = $1
+FOR UPDATE;
+
+-- name: MarkRefreshTokenUsed :exec
+UPDATE refresh_tokens
+SET used_at = NOW()
+WHERE id = $1;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE family_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: ListSigningKeys :many
+SELECT * FROM signing_keys
+ORDER BY activates_at;
+
+-- name: CreateSigningKey :exec
+INSERT INTO signing_keys (kid, algorithm, private_key, created_at, activates_at)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: RetireActiveSigningKeys :exec
+UPDATE signing_keys
+SET retired_at = sqlc.arg(retired_at)::TIMESTAMPTZ
+WHERE retired_at IS NULL;
+
+-- name: DeleteSigningKeysRetiredBefore :exec
+DELETE FROM signing_keys
+WHERE retired_at < sqlc.arg(before)::TIMESTAMPTZ;
+
+-- name: RevokeRefreshTokenFamilyByHash :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE family_id = (SELECT rt.family_id FROM refresh_tokens rt WHERE rt.token_hash = $1)
+    AND revoked_at IS NULL;
+
+-- name: RevokeToken :exec
+INSERT INTO revoked_tokens (jti, expires_at)
+VALUES ($1, $2)
+ON CONFLICT (jti) DO NOTHING;
+
+-- name: IsTokenRevoked :one
+SELECT EXISTS (
+    SELECT 1 FROM revoked_tokens WHERE jti = $1
+);
+
+-- name: PurgeRevokedTokens :execrows
+DELETE FROM revoked_tokens
+WHERE expires_at < $1;
+
+
+-- name: GetUserTokenState :one
+SELECT u.token_version, EXISTS (
+    SELECT 1 FROM user_bans b
+    WHERE b.org_id = sqlc.arg(org_id) AND b.user_id = u.id
+) AS is_banned
+FROM users u
+WHERE u.id = sqlc.arg(id);
+
+-- name: IsUserBanned :one
+SELECT EXISTS (
+    SELECT 1 FROM user_bans WHERE org_id = $1 AND user_id = $2
+);
+
+-- name: ListBannedUsers :many
+SELECT user_id FROM user_bans
+WHERE org_id = sqlc.arg(org_id) AND user_id = ANY(sqlc.arg(user_ids)::INTEGER[]);
+
+-- name: IsOrganizationMember :one
+SELECT EXISTS (
+    SELECT 1 FROM user_roles
+    WHERE org_id = $1 AND user_id = $2
+        AND (expires_at IS NULL OR expires_at > NOW())
+);
+
+-- name: BanUser :exec
+INSERT INTO user_bans (org_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT (org_id, user_id) DO NOTHING;
+
+-- name: UnbanUser :exec
+DELETE FROM user_bans
+WHERE org_id = $1 AND user_id = $2;
+
+-- name: UpdateUserPassword :execrows
+UPDATE users
+SET password_hash = $2,
+    token_version = token_version + 1
+WHERE id = $1;
+
+-- name: UpsertClient :exec
+INSERT INTO clients (client_id, redirect_uris, audiences, secret_hash, role_id)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    (SELECT id FROM roles WHERE alias = $5)
+)
+ON CONFLICT (client_id)
+DO UPDATE SET
+    redirect_uris = EXCLUDED.redirect_uris,
+    audiences = EXCLUDED.audiences,
+    secret_hash = EXCLUDED.secret_hash,
+    role_id = EXCLUDED.role_id;
+
+-- name: GetClientByClientID :one
+SELECT c.*, r.alias, r.permissions
+FROM clients c LEFT JOIN roles r
+ON c.role_id = r.id
+WHERE c.client_id = $1;
+
+-- name: CreateAuthorizationCode :exec
+INSERT INTO authorization_codes (code_hash, client_id, user_id, redirect_uri, code_challenge, scope, nonce, auth_time, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+
+-- name: ConsumeAuthorizationCode :one
+DELETE FROM authorization_codes
+WHERE code_hash = $1
+RETURNING *;
+
+-- name: ListPermissions :many
+SELECT * FROM permissions
+ORDER BY bit;
+
+-- name: CreatePermission :exec
+INSERT INTO permissions (bit, name)
+VALUES ($1, $2);
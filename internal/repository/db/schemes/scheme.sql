@@ -3,15 +3,116 @@ CREATE TABLE IF NOT EXISTS roles (
     alias VARCHAR(64) UNIQUE NOT NULL,
     is_default BOOLEAN NOT NULL DEFAULT false,
     is_super BOOLEAN NOT NULL DEFAULT false,
-    permissions_mask BIGINT NOT NULL DEFAULT 0
+    permissions BYTEA NOT NULL DEFAULT ''::bytea,
+    own_permissions BYTEA NOT NULL DEFAULT ''::bytea,
+    overridden BOOLEAN NOT NULL DEFAULT false
 );
 
+CREATE TABLE IF NOT EXISTS organizations (
+    id SERIAL PRIMARY KEY,
+    alias VARCHAR(64) UNIQUE NOT NULL,
+    name VARCHAR(128) NOT NULL DEFAULT '',
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS organizations_is_default_idx ON organizations(is_default)
+WHERE is_default;
+
 CREATE TABLE IF NOT EXISTS users (
     id SERIAL PRIMARY KEY,
     login VARCHAR(128) UNIQUE NOT NULL,
-    role_id SERIAL REFERENCES roles(id) NOT NULL,
     password_hash VARCHAR(256) NOT NULL,
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    token_version INTEGER NOT NULL DEFAULT 0,
 
     CHECK ( length(login) >= 3 )
-);
\ No newline at end of file
+);
+
+CREATE INDEX IF NOT EXISTS users_created_at_id_idx ON users(created_at, id);
+CREATE INDEX IF NOT EXISTS users_login_pattern_idx ON users(login varchar_pattern_ops);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    role_id INTEGER REFERENCES roles(id) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ,
+    org_id INTEGER REFERENCES organizations(id) ON DELETE CASCADE NOT NULL,
+
+    PRIMARY KEY (org_id, user_id, role_id)
+);
+
+CREATE INDEX IF NOT EXISTS user_roles_role_id_idx ON user_roles(role_id);
+CREATE INDEX IF NOT EXISTS user_roles_user_id_idx ON user_roles(user_id);
+CREATE INDEX IF NOT EXISTS user_roles_expires_at_idx ON user_roles(expires_at)
+WHERE expires_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS user_bans (
+    org_id INTEGER REFERENCES organizations(id) ON DELETE CASCADE NOT NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (org_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id SERIAL PRIMARY KEY,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    family_id VARCHAR(64) NOT NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    client_id VARCHAR(64) NOT NULL DEFAULT '',
+    org_id INTEGER REFERENCES organizations(id) ON DELETE CASCADE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_id_idx ON refresh_tokens(family_id);
+CREATE INDEX IF NOT EXISTS refresh_tokens_user_id_idx ON refresh_tokens(user_id);
+
+CREATE TABLE IF NOT EXISTS signing_keys (
+    kid VARCHAR(64) PRIMARY KEY,
+    algorithm VARCHAR(16) NOT NULL,
+    private_key BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    retired_at TIMESTAMPTZ,
+    activates_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS revoked_tokens_expires_at_idx ON revoked_tokens(expires_at);
+
+CREATE TABLE IF NOT EXISTS clients (
+    id SERIAL PRIMARY KEY,
+    client_id VARCHAR(64) UNIQUE NOT NULL,
+    redirect_uris TEXT[] NOT NULL DEFAULT '{}',
+    audiences TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    secret_hash TEXT,
+    role_id INTEGER REFERENCES roles(id)
+);
+
+CREATE TABLE IF NOT EXISTS authorization_codes (
+    code_hash VARCHAR(64) PRIMARY KEY,
+    client_id VARCHAR(64) REFERENCES clients(client_id) ON DELETE CASCADE NOT NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    redirect_uri TEXT NOT NULL,
+    code_challenge VARCHAR(128) NOT NULL,
+    scope TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    nonce TEXT NOT NULL DEFAULT '',
+    auth_time TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    bit SMALLINT PRIMARY KEY CHECK ( bit >= 0 AND bit <= 1023 ),
+    name VARCHAR(64) UNIQUE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/repository/db"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/tokenizer"
+)
+
+func (r *Repository) ListSigningKeys(
+	ctx context.Context,
+) (keys []tokenizer.StoredKey, err error) {
+	const src = "Repository.ListSigningKeys"
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to list signing keys: %w", src, err)
+		}
+	}()
+
+	entities, err := r.queries.ListSigningKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys = make([]tokenizer.StoredKey, 0, len(entities))
+	for _, entity := range entities {
+		key := tokenizer.StoredKey{
+			ID:          entity.Kid,
+			Algorithm:   entity.Algorithm,
+			PrivateKey:  entity.PrivateKey,
+			CreatedAt:   entity.CreatedAt,
+			ActivatesAt: entity.ActivatesAt,
+		}
+
+		if entity.RetiredAt.Valid {
+			key.RetiredAt = &entity.RetiredAt.Time
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func (r *Repository) CreateSigningKey(
+	ctx context.Context,
+	key tokenizer.StoredKey,
+) (err error) {
+	const src = "Repository.CreateSigningKey"
+	log := r.log.With(slog.String("src", src))
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to create signing key: %w", src, err)
+		}
+	}()
+
+	log.Debug("creating signing key", slog.String("kid", key.ID))
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := r.queries.WithTx(tx)
+
+	// The keys in use keep signing until the new one takes over.
+	if err := q.RetireActiveSigningKeys(ctx, key.ActivatesAt); err != nil {
+		return err
+	}
+
+	err = q.CreateSigningKey(ctx, db.CreateSigningKeyParams{
+		Kid:         key.ID,
+		Algorithm:   key.Algorithm,
+		PrivateKey:  key.PrivateKey,
+		CreatedAt:   key.CreatedAt,
+		ActivatesAt: key.ActivatesAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) DeleteSigningKeysRetiredBefore(
+	ctx context.Context,
+	before time.Time,
+) (err error) {
+	const src = "Repository.DeleteSigningKeysRetiredBefore"
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to delete signing keys: %w", src, err)
+		}
+	}()
+
+	return r.queries.DeleteSigningKeysRetiredBefore(ctx, before)
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/repository/db"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/usecases"
+)
+
+func (r *Repository) UpsertOrganization(
+	ctx context.Context,
+	alias string,
+	name string,
+) (err error) {
+	const src = "Repository.UpsertOrganization"
+	log := r.log.With(slog.String("src", src))
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to save organization: %w", src, err)
+		}
+	}()
+
+	log.Debug("saving organization", slog.String("alias", alias))
+
+	return r.queries.UpsertOrganization(ctx, db.UpsertOrganizationParams{
+		Alias: alias,
+		Name:  name,
+	})
+}
+
+func (r *Repository) ListUserOrganizations(
+	ctx context.Context,
+	userID int32,
+) (organizations []*usecases.OrganizationModel, err error) {
+	const src = "Repository.ListUserOrganizations"
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to list organizations: %w", src, err)
+		}
+	}()
+
+	entities, err := r.queries.ListUserOrganizations(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	organizations = make([]*usecases.OrganizationModel, 0, len(entities))
+	for _, entity := range entities {
+		organizations = append(organizations, &usecases.OrganizationModel{
+			ID:        entity.ID,
+			Alias:     entity.Alias,
+			Name:      entity.Name,
+			IsDefault: entity.IsDefault,
+		})
+	}
+
+	return organizations, nil
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/repository/db"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/roles"
+)
+
+func (r *Repository) ListPermissions(
+	ctx context.Context,
+) (permissions []roles.PermissionDefinition, err error) {
+	const src = "Repository.ListPermissions"
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to fetch permissions: %w", src, err)
+		}
+	}()
+
+	entities, err := r.queries.ListPermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions = make([]roles.PermissionDefinition, 0, len(entities))
+	for _, entity := range entities {
+		permissions = append(permissions, roles.PermissionDefinition{
+			Name: entity.Name,
+			Bit:  int(entity.Bit),
+		})
+	}
+
+	return permissions, nil
+}
+
+func (r *Repository) CreatePermission(
+	ctx context.Context,
+	name string,
+	bit int,
+) (err error) {
+	const src = "Repository.CreatePermission"
+	log := r.log.With(slog.String("src", src))
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to create permission: %w", src, err)
+		}
+	}()
+
+	log.Debug("creating permission", slog.String("name", name), slog.Int("bit", bit))
+
+	return r.queries.CreatePermission(ctx, db.CreatePermissionParams{
+		Bit:  int16(bit),
+		Name: name,
+	})
+}
@@ -6,9 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
 	"github.com/AleksandrVishniakov/jwt-auth/internal/repository/db"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/roles"
 	"github.com/AleksandrVishniakov/jwt-auth/internal/usecases"
 )
 
@@ -71,6 +75,10 @@ func (r *Repository) CreateUser(
 		return 0, err
 	}
 
+	if err := q.AssignDefaultRole(ctx, id); err != nil {
+		return 0, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return 0, err
 	}
@@ -78,6 +86,9 @@ func (r *Repository) CreateUser(
 	return id, nil
 }
 
+// CreateSuperUser creates a user with the super role in every organization.
+// When the user exists and is the super user of the default organization, it
+// gets the super role in the organizations added since.
 func (r *Repository) CreateSuperUser(
 	ctx context.Context,
 	login string,
@@ -101,16 +112,24 @@ func (r *Repository) CreateSuperUser(
 
 	q := r.queries.WithTx(tx)
 
-	_, err = q.GetUserByLogin(ctx, login)
+	existing, err := q.GetUserByLogin(ctx, login)
 	if !errors.Is(err, sql.ErrNoRows) {
 		if err != nil {
 			return 0, err
 		}
 
+		if err := r.extendSuperUser(ctx, q, existing.ID); err != nil {
+			return 0, err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return 0, err
+		}
+
 		return 0, e.ErrAlreadyExists
 	}
 
-	id, err = q.CreateSuperUser(ctx, db.CreateSuperUserParams{
+	id, err = q.CreateUser(ctx, db.CreateUserParams{
 		Login:        login,
 		PasswordHash: string(passworHash),
 	})
@@ -119,6 +138,10 @@ func (r *Repository) CreateSuperUser(
 		return 0, err
 	}
 
+	if err := q.AssignSuperRole(ctx, id); err != nil {
+		return 0, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return 0, err
 	}
@@ -126,8 +149,37 @@ func (r *Repository) CreateSuperUser(
 	return id, nil
 }
 
+// extendSuperUser gives the super role in every organization to a super
+// user of the default organization.
+func (r *Repository) extendSuperUser(ctx context.Context, q *db.Queries, userID int32) error {
+	orgID, err := organizationID(ctx, q, 0)
+	if err != nil {
+		return err
+	}
+
+	entities, err := q.ListUserRoles(ctx, db.ListUserRolesParams{
+		OrgID:  orgID,
+		UserID: userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, role := range entities {
+		if role.IsSuper && !role.ExpiresAt.Valid {
+			return q.AssignSuperRole(ctx, userID)
+		}
+	}
+
+	return nil
+}
+
+// GetUserById fetches a user with the roles held in an organization, the
+// default one when orgID is zero. Users outside the organization are not
+// found.
 func (r *Repository) GetUserById(
 	ctx context.Context,
+	orgID int32,
 	id int32,
 ) (user *usecases.UserModel, err error) {
 	const src = "Repository.GetUserById"
@@ -149,13 +201,7 @@ func (r *Repository) GetUserById(
 		return nil, err
 	}
 
-	return &usecases.UserModel{
-		ID:             id,
-		Login:          entity.Login,
-		PasswordHash:   entity.PasswordHash,
-		Role:           entity.Alias,
-		PermissionMask: entity.PermissionsMask,
-	}, nil
+	return r.userModel(ctx, entity, orgID)
 }
 
 func (r *Repository) GetUserByLogin(
@@ -180,67 +226,668 @@ func (r *Repository) GetUserByLogin(
 		return nil, err
 	}
 
-	return &usecases.UserModel{
-		ID:             entity.ID,
-		Login:          entity.Login,
-		PasswordHash:   entity.PasswordHash,
-		Role:           entity.Alias,
-		PermissionMask: entity.PermissionsMask,
-	}, nil
+	return r.userModel(ctx, entity, 0)
 }
 
-func (r *Repository) UpsertRole(
+// SaveRoles upserts the roles declared in config in one transaction, so that
+// roles are never saved with permissions inherited from an older version of
+// their parents. The tokens of the users holding a role whose permissions
+// changed are outdated.
+//
+// Changes made through the API take precedence over config: an overridden
+// role keeps its stored permissions and flags, and a default or super flag
+// given through the API is not taken back by the role config declares.
+func (r *Repository) SaveRoles(
 	ctx context.Context,
-	alias string,
-	mask int64,
-	isDefault bool,
-	isSuper bool,
-) (id int32, err error) {
-	const src = "Repository.UpsertRole"
+	resolved []roles.ResolvedRole,
+) (err error) {
+	const src = "Repository.SaveRoles"
 	log := r.log.With(slog.String("src", src))
 	defer func() {
 		if err != nil {
-			err = fmt.Errorf("%s: failed to create role: %w", src, err)
+			err = fmt.Errorf("%s: failed to save roles: %w", src, err)
 		}
 	}()
 
-	log.Debug("upserting new role", slog.String("alias", alias))
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := r.queries.WithTx(tx)
+
+	stored, err := q.ListRoles(ctx)
+	if err != nil {
+		return err
+	}
+
+	var defaultRole, superRole *db.Role
+	overridden := make(map[string]bool, len(stored))
+	for i, entity := range stored {
+		overridden[entity.Alias] = entity.Overridden
+
+		if entity.IsDefault {
+			defaultRole = &stored[i]
+		}
+
+		if entity.IsSuper {
+			superRole = &stored[i]
+		}
+	}
+
+	for _, role := range resolved {
+		if overridden[role.Alias] {
+			log.Info("role was changed through the API, keeping it", slog.String("alias", role.Alias))
+			continue
+		}
+
+		log.Debug("upserting role", slog.String("alias", role.Alias))
+
+		isDefault := role.Default && !heldThroughAPI(defaultRole, role.Alias)
+		if role.Default && !isDefault {
+			log.Warn("default role was changed through the API, keeping it", slog.String("alias", defaultRole.Alias))
+		}
+
+		isSuper := role.Super && !heldThroughAPI(superRole, role.Alias)
+		if role.Super && !isSuper {
+			log.Warn("super role was changed through the API, keeping it", slog.String("alias", superRole.Alias))
+		}
+
+		id, err := q.UpsertRole(ctx, db.UpsertRoleParams{
+			Alias:          role.Alias,
+			OwnPermissions: role.Permissions,
+			IsDefault:      isDefault,
+			IsSuper:        isSuper,
+		})
+		if err != nil {
+			return fmt.Errorf("role %s: %w", role.Alias, err)
+		}
+
+		if isDefault {
+			if err := q.ClearDefaultRole(ctx, id); err != nil {
+				return fmt.Errorf("role %s: %w", role.Alias, err)
+			}
+		}
 
-	id, err = r.queries.UpsertRole(ctx, db.UpsertRoleParams{
-		Alias:           alias,
-		PermissionsMask: mask,
-		IsDefault:       isDefault,
-		IsSuper:         isSuper,
+		if isSuper {
+			if err := q.ClearSuperRole(ctx, id); err != nil {
+				return fmt.Errorf("role %s: %w", role.Alias, err)
+			}
+		}
+	}
+
+	if err := resolvePermissions(ctx, q, resolved); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// heldThroughAPI tells whether a flag was given through the API to another
+// role than alias.
+func heldThroughAPI(holder *db.Role, alias string) bool {
+	return holder != nil && holder.Overridden && holder.Alias != alias
+}
+
+// AssignRole adds a role to the user in an organization, making the user a
+// member of it, and outdates the user tokens. A role with a zero expiresAt is
+// permanent. Assigning a role the user already has permanently changes
+// nothing, a temporary one gets the new expiry.
+func (r *Repository) AssignRole(
+	ctx context.Context,
+	orgID int32,
+	userID int32,
+	roleID int32,
+	expiresAt time.Time,
+) (err error) {
+	const src = "Repository.AssignRole"
+	log := r.log.With(slog.String("src", src))
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to assign role: %w", src, err)
+		}
+	}()
+
+	log.Debug("assigning user role", slog.Int("id", int(userID)), slog.Int("role_id", int(roleID)))
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := r.queries.WithTx(tx)
+
+	orgID, err = organizationID(ctx, q, orgID)
+	if err != nil {
+		return err
+	}
+
+	users, err := q.BumpTokenVersion(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if users == 0 {
+		return e.ErrNotFound
+	}
+
+	assigned, err := q.AssignUserRole(ctx, db.AssignUserRoleParams{
+		OrgID:  orgID,
+		UserID: userID,
+		RoleID: roleID,
+		ExpiresAt: sql.NullTime{
+			Time:  expiresAt,
+			Valid: !expiresAt.IsZero(),
+		},
 	})
 	if err != nil {
-		return 0, err
+		return err
 	}
 
-	return id, nil
+	if assigned == 0 {
+		return nil
+	}
+
+	return tx.Commit()
 }
 
-func (r *Repository) UpdateRoleById(
+// UnassignRole takes a role from the user in an organization and outdates
+// the user tokens. The last role of a member is not taken, nor the super role
+// of the last super user of the organization. The role row stays locked until
+// commit so that concurrent demotions cannot remove the last super user
+// together.
+func (r *Repository) UnassignRole(
 	ctx context.Context,
+	orgID int32,
 	userID int32,
-	roleAlias string,
+	roleID int32,
 ) (err error) {
-	const src = "Repository.UpdateRoleById"
+	const src = "Repository.UnassignRole"
 	log := r.log.With(slog.String("src", src))
 	defer func() {
 		if err != nil {
-			err = fmt.Errorf("%s: failed to update role: %w", src, err)
+			err = fmt.Errorf("%s: failed to unassign role: %w", src, err)
+		}
+	}()
+
+	log.Debug("unassigning user role", slog.Int("id", int(userID)), slog.Int("role_id", int(roleID)))
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := r.queries.WithTx(tx)
+
+	orgID, err = organizationID(ctx, q, orgID)
+	if err != nil {
+		return err
+	}
+
+	role, err := q.GetRoleForUpdate(ctx, roleID)
+	if err != nil {
+		return err
+	}
+
+	users, err := q.BumpTokenVersion(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if users == 0 {
+		return e.ErrNotFound
+	}
+
+	unassigned, err := q.UnassignUserRole(ctx, db.UnassignUserRoleParams{
+		OrgID:  orgID,
+		UserID: userID,
+		RoleID: roleID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if unassigned == 0 {
+		return e.ErrRoleNotAssigned
+	}
+
+	left, err := q.CountUserRoles(ctx, db.CountUserRolesParams{
+		OrgID:  orgID,
+		UserID: userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if left == 0 {
+		return e.ErrLastRole
+	}
+
+	if role.IsSuper {
+		supers, err := q.CountSuperUsers(ctx, orgID)
+		if err != nil {
+			return err
+		}
+
+		if supers == 0 {
+			return e.ErrLastSuperUser
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListRoleGrants lists the temporary role assignments of an organization
+// expiring after now, the soonest first.
+func (r *Repository) ListRoleGrants(
+	ctx context.Context,
+	orgID int32,
+	now time.Time,
+) (grants []*usecases.RoleGrantModel, err error) {
+	const src = "Repository.ListRoleGrants"
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to list role grants: %w", src, err)
+		}
+	}()
+
+	orgID, err = organizationID(ctx, r.queries, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	entities, err := r.queries.ListActiveRoleGrants(ctx, db.ListActiveRoleGrantsParams{
+		OrgID:     orgID,
+		ExpiresAt: sql.NullTime{Time: now, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	grants = make([]*usecases.RoleGrantModel, 0, len(entities))
+	for _, entity := range entities {
+		grants = append(grants, &usecases.RoleGrantModel{
+			UserID:    entity.UserID,
+			Login:     entity.Login,
+			Role:      entity.Alias,
+			GrantedAt: entity.CreatedAt,
+			ExpiresAt: entity.ExpiresAt.Time,
+		})
+	}
+
+	return grants, nil
+}
+
+// ExpireRoleGrants removes the temporary role assignments expired by now and
+// outdates the tokens of their users, who are left with their other roles.
+func (r *Repository) ExpireRoleGrants(
+	ctx context.Context,
+	now time.Time,
+) (userIDs []int32, err error) {
+	const src = "Repository.ExpireRoleGrants"
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to expire role grants: %w", src, err)
 		}
 	}()
 
-	log.Debug("updating user role", slog.Int("id", int(userID)))
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.queries.WithTx(tx)
+
+	userIDs, err = q.DeleteExpiredRoleGrants(ctx, sql.NullTime{Time: now, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
 
-	err = r.queries.UpdateRoleById(ctx, db.UpdateRoleByIdParams{
+	slices.Sort(userIDs)
+	userIDs = slices.Compact(userIDs)
+
+	err = q.BumpTokenVersions(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return userIDs, tx.Commit()
+}
+
+// GetUserTokenState fetches the token version of a user and whether the user
+// is banned in an organization, the default one when orgID is zero.
+func (r *Repository) GetUserTokenState(
+	ctx context.Context,
+	userID int32,
+	orgID int32,
+) (version int32, banned bool, err error) {
+	const src = "Repository.GetUserTokenState"
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to fetch token state: %w", src, err)
+		}
+	}()
+
+	orgID, err = organizationID(ctx, r.queries, orgID)
+	if err != nil {
+		return 0, false, err
+	}
+
+	state, err := r.queries.GetUserTokenState(ctx, db.GetUserTokenStateParams{
+		OrgID: orgID,
 		ID:    userID,
-		Alias: roleAlias,
 	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, e.ErrNotFound
+		}
+
+		return 0, false, err
+	}
+
+	return state.TokenVersion, state.IsBanned, nil
+}
+
+// SetUserBanned bans or unbans a user in an organization the user is a member
+// of. The ban only applies to that organization. Users outside of it are
+// reported as e.ErrNotFound.
+func (r *Repository) SetUserBanned(
+	ctx context.Context,
+	orgID int32,
+	userID int32,
+	banned bool,
+) (err error) {
+	const src = "Repository.SetUserBanned"
+	log := r.log.With(slog.String("src", src))
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to update user: %w", src, err)
+		}
+	}()
+
+	log.Debug("updating user ban", slog.Int("id", int(userID)), slog.Bool("banned", banned))
+
+	orgID, err = organizationID(ctx, r.queries, orgID)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	member, err := r.queries.IsOrganizationMember(ctx, db.IsOrganizationMemberParams{
+		OrgID:  orgID,
+		UserID: userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !member {
+		return e.ErrNotFound
+	}
+
+	if banned {
+		return r.queries.BanUser(ctx, db.BanUserParams{
+			OrgID:  orgID,
+			UserID: userID,
+		})
+	}
+
+	return r.queries.UnbanUser(ctx, db.UnbanUserParams{
+		OrgID:  orgID,
+		UserID: userID,
+	})
+}
+
+// ChangePassword replaces the password hash of a user. The tokens issued
+// before go stale and every refresh token of the user is revoked.
+func (r *Repository) ChangePassword(
+	ctx context.Context,
+	userID int32,
+	passwordHash []byte,
+) (err error) {
+	const src = "Repository.ChangePassword"
+	log := r.log.With(slog.String("src", src))
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to change password: %w", src, err)
+		}
+	}()
+
+	log.Debug("changing password", slog.Int("id", int(userID)))
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := r.queries.WithTx(tx)
+
+	rows, err := q.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+		ID:           userID,
+		PasswordHash: string(passwordHash),
+	})
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return e.ErrNotFound
+	}
+
+	err = q.RevokeUserRefreshTokens(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// likeEscaper escapes the characters LIKE treats as wildcards, so that a login
+// prefix matches literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (r *Repository) ListUsers(
+	ctx context.Context,
+	filter *usecases.UserFilter,
+) (users []*usecases.UserListItemModel, err error) {
+	const src = "Repository.ListUsers"
+	log := r.log.With(slog.String("src", src))
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to list users: %w", src, err)
+		}
+	}()
+
+	log.Debug("listing users", slog.String("sort", string(filter.Sort)))
+
+	orgID, err := organizationID(ctx, r.queries, filter.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	role := sql.NullString{
+		String: filter.Role,
+		Valid:  filter.Role != "",
+	}
+	loginPrefix := sql.NullString{
+		String: likeEscaper.Replace(filter.LoginPrefix),
+		Valid:  filter.LoginPrefix != "",
+	}
+	createdFrom := sql.NullTime{
+		Time:  filter.CreatedFrom,
+		Valid: !filter.CreatedFrom.IsZero(),
+	}
+	createdTo := sql.NullTime{
+		Time:  filter.CreatedTo,
+		Valid: !filter.CreatedTo.IsZero(),
+	}
+
+	var afterLogin sql.NullString
+	var afterCreatedAt sql.NullTime
+	var afterID int32
+	if filter.After != nil {
+		afterLogin = sql.NullString{String: filter.After.Login, Valid: true}
+		afterCreatedAt = sql.NullTime{Time: filter.After.CreatedAt, Valid: true}
+		afterID = filter.After.ID
+	}
+
+	var entities []db.User
+	switch filter.Sort {
+	case usecases.SortByLogin:
+		entities, err = r.queries.ListUsersByLogin(ctx, db.ListUsersByLoginParams{
+			OrgID:       orgID,
+			Role:        role,
+			LoginPrefix: loginPrefix,
+			CreatedFrom: createdFrom,
+			CreatedTo:   createdTo,
+			AfterLogin:  afterLogin,
+			PageSize:    int32(filter.Limit),
+		})
+	case usecases.SortByLoginDesc:
+		entities, err = r.queries.ListUsersByLoginDesc(ctx, db.ListUsersByLoginDescParams{
+			OrgID:       orgID,
+			Role:        role,
+			LoginPrefix: loginPrefix,
+			CreatedFrom: createdFrom,
+			CreatedTo:   createdTo,
+			AfterLogin:  afterLogin,
+			PageSize:    int32(filter.Limit),
+		})
+	case usecases.SortByCreatedAt:
+		entities, err = r.queries.ListUsersByCreatedAt(ctx, db.ListUsersByCreatedAtParams{
+			OrgID:          orgID,
+			Role:           role,
+			LoginPrefix:    loginPrefix,
+			CreatedFrom:    createdFrom,
+			CreatedTo:      createdTo,
+			AfterCreatedAt: afterCreatedAt,
+			AfterID:        afterID,
+			PageSize:       int32(filter.Limit),
+		})
+	case usecases.SortByCreatedAtDesc:
+		entities, err = r.queries.ListUsersByCreatedAtDesc(ctx, db.ListUsersByCreatedAtDescParams{
+			OrgID:          orgID,
+			Role:           role,
+			LoginPrefix:    loginPrefix,
+			CreatedFrom:    createdFrom,
+			CreatedTo:      createdTo,
+			AfterCreatedAt: afterCreatedAt,
+			AfterID:        afterID,
+			PageSize:       int32(filter.Limit),
+		})
+	default:
+		return nil, fmt.Errorf("unknown sort %q", filter.Sort)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int32, 0, len(entities))
+	for _, entity := range entities {
+		ids = append(ids, entity.ID)
+	}
+
+	bannedIDs, err := r.queries.ListBannedUsers(ctx, db.ListBannedUsersParams{
+		OrgID:   orgID,
+		UserIds: ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	users = make([]*usecases.UserListItemModel, 0, len(entities))
+	for _, entity := range entities {
+		users = append(users, &usecases.UserListItemModel{
+			ID:        entity.ID,
+			Login:     entity.Login,
+			CreatedAt: entity.CreatedAt,
+			IsBanned:  slices.Contains(bannedIDs, entity.ID),
+		})
+	}
+
+	return users, nil
+}
+
+// userModel completes a user with its roles in an organization, the
+// default one when orgID is zero. The user permissions are the union of the
+// permissions of every role. Users without roles in the organization are not
+// its members and are not found. The ban status is the one in the
+// organization too.
+func (r *Repository) userModel(
+	ctx context.Context,
+	entity db.User,
+	orgID int32,
+) (*usecases.UserModel, error) {
+	orgID, err := organizationID(ctx, r.queries, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	entities, err := r.queries.ListUserRoles(ctx, db.ListUserRolesParams{
+		OrgID:  orgID,
+		UserID: entity.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entities) == 0 {
+		return nil, e.ErrNotFound
+	}
+
+	banned, err := r.queries.IsUserBanned(ctx, db.IsUserBannedParams{
+		OrgID:  orgID,
+		UserID: entity.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		aliases        = make([]string, 0, len(entities))
+		mask           roles.Mask
+		grantExpiresAt time.Time
+	)
+	for _, role := range entities {
+		aliases = append(aliases, role.Alias)
+		mask = mask.Union(roles.Mask(role.Permissions))
+
+		if role.ExpiresAt.Valid && (grantExpiresAt.IsZero() || role.ExpiresAt.Time.Before(grantExpiresAt)) {
+			grantExpiresAt = role.ExpiresAt.Time
+		}
+	}
+
+	return &usecases.UserModel{
+		ID:             entity.ID,
+		Login:          entity.Login,
+		PasswordHash:   entity.PasswordHash,
+		Roles:          aliases,
+		PermissionMask: mask,
+		TokenVersion:   entity.TokenVersion,
+		IsBanned:       banned,
+		OrgID:          orgID,
+		GrantExpiresAt: grantExpiresAt,
+	}, nil
+}
+
+// organizationID resolves zero to the default organization.
+func organizationID(ctx context.Context, q *db.Queries, orgID int32) (int32, error) {
+	if orgID != 0 {
+		return orgID, nil
+	}
+
+	org, err := q.GetDefaultOrganization(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return org.ID, nil
 }
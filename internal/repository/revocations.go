@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/repository/db"
+)
+
+// RevocationStore is the Postgres backed revocation.Store.
+type RevocationStore struct {
+	log     *slog.Logger
+	queries *db.Queries
+}
+
+func NewRevocationStore(
+	log *slog.Logger,
+	queries *db.Queries,
+) *RevocationStore {
+	return &RevocationStore{
+		log:     log,
+		queries: queries,
+	}
+}
+
+func (r *RevocationStore) Revoke(
+	ctx context.Context,
+	jti string,
+	expiresAt time.Time,
+) (err error) {
+	const src = "RevocationStore.Revoke"
+	log := r.log.With(slog.String("src", src))
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to revoke token: %w", src, err)
+		}
+	}()
+
+	log.Debug("revoking token", slog.String("jti", jti))
+
+	return r.queries.RevokeToken(ctx, db.RevokeTokenParams{
+		Jti:       jti,
+		ExpiresAt: expiresAt,
+	})
+}
+
+func (r *RevocationStore) IsRevoked(
+	ctx context.Context,
+	jti string,
+) (revoked bool, err error) {
+	const src = "RevocationStore.IsRevoked"
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to check token: %w", src, err)
+		}
+	}()
+
+	return r.queries.IsTokenRevoked(ctx, jti)
+}
+
+func (r *RevocationStore) Purge(
+	ctx context.Context,
+	now time.Time,
+) (purged int64, err error) {
+	const src = "RevocationStore.Purge"
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to purge tokens: %w", src, err)
+		}
+	}()
+
+	return r.queries.PurgeRevokedTokens(ctx, now)
+}
@@ -0,0 +1,371 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/repository/db"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/roles"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/usecases"
+)
+
+func (r *Repository) ListRoles(
+	ctx context.Context,
+) (roles []*usecases.RoleModel, err error) {
+	const src = "Repository.ListRoles"
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to fetch roles: %w", src, err)
+		}
+	}()
+
+	entities, err := r.queries.ListRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	roles = make([]*usecases.RoleModel, 0, len(entities))
+	for _, entity := range entities {
+		roles = append(roles, roleModel(entity))
+	}
+
+	return roles, nil
+}
+
+func (r *Repository) GetRole(
+	ctx context.Context,
+	alias string,
+) (role *usecases.RoleModel, err error) {
+	const src = "Repository.GetRole"
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to fetch role: %w", src, err)
+		}
+	}()
+
+	entity, err := r.queries.GetRoleByAlias(ctx, alias)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, e.ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return roleModel(entity), nil
+}
+
+// CreateRole saves a new role. A new default or super role replaces the
+// previous one. The roles of config inheriting from it, when it was declared
+// there and deleted, get its permissions again.
+func (r *Repository) CreateRole(
+	ctx context.Context,
+	role *usecases.RoleModel,
+	hierarchy []roles.ResolvedRole,
+) (err error) {
+	const src = "Repository.CreateRole"
+	log := r.log.With(slog.String("src", src))
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to create role: %w", src, err)
+		}
+	}()
+
+	log.Debug("creating role", slog.String("alias", role.Alias))
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := r.queries.WithTx(tx)
+
+	_, err = q.GetRoleByAlias(ctx, role.Alias)
+	if !errors.Is(err, sql.ErrNoRows) {
+		if err != nil {
+			return err
+		}
+
+		return e.ErrAlreadyExists
+	}
+
+	id, err := q.CreateRole(ctx, db.CreateRoleParams{
+		Alias:       role.Alias,
+		IsDefault:   role.IsDefault,
+		IsSuper:     role.IsSuper,
+		Permissions: role.PermissionMask,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := moveRoleFlags(ctx, q, id, role.IsDefault, role.IsSuper); err != nil {
+		return err
+	}
+
+	if err := resolvePermissions(ctx, q, hierarchy); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateRole changes the permissions and flags of a role and marks it
+// overridden, so that SyncRoles keeps the change over config. The tokens of
+// its users and of the users of the roles of config inheriting from it are
+// outdated, they carry the previous permissions.
+//
+// Exactly one role is default and one super: the flags are taken from other
+// roles by setting them, a role never gives them up on its own.
+func (r *Repository) UpdateRole(
+	ctx context.Context,
+	role *usecases.RoleModel,
+	hierarchy []roles.ResolvedRole,
+) (err error) {
+	const src = "Repository.UpdateRole"
+	log := r.log.With(slog.String("src", src))
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to update role: %w", src, err)
+		}
+	}()
+
+	log.Debug("updating role", slog.String("alias", role.Alias))
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := r.queries.WithTx(tx)
+
+	entity, err := q.GetRoleByAlias(ctx, role.Alias)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return e.ErrNotFound
+		}
+
+		return err
+	}
+
+	if entity.IsDefault && !role.IsDefault {
+		return e.ErrDefaultRoleRequired
+	}
+
+	if entity.IsSuper && !role.IsSuper {
+		return e.ErrSuperRoleRequired
+	}
+
+	err = q.UpdateRole(ctx, db.UpdateRoleParams{
+		ID:          entity.ID,
+		IsDefault:   role.IsDefault,
+		IsSuper:     role.IsSuper,
+		Permissions: role.PermissionMask,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = moveRoleFlags(ctx, q, entity.ID, role.IsDefault && !entity.IsDefault, role.IsSuper && !entity.IsSuper)
+	if err != nil {
+		return err
+	}
+
+	if err := q.BumpTokenVersionByRole(ctx, entity.ID); err != nil {
+		return err
+	}
+
+	if err := resolvePermissions(ctx, q, hierarchy); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteRole removes a role. Its users and clients are moved to the
+// reassignTo role; without one a role that is still held is not deleted. The
+// roles of config inheriting from it lose its permissions.
+func (r *Repository) DeleteRole(
+	ctx context.Context,
+	alias string,
+	reassignTo string,
+	hierarchy []roles.ResolvedRole,
+) (err error) {
+	const src = "Repository.DeleteRole"
+	log := r.log.With(slog.String("src", src))
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to delete role: %w", src, err)
+		}
+	}()
+
+	log.Debug("deleting role", slog.String("alias", alias), slog.String("reassign_to", reassignTo))
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := r.queries.WithTx(tx)
+
+	entity, err := q.GetRoleByAlias(ctx, alias)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return e.ErrNotFound
+		}
+
+		return err
+	}
+
+	if reassignTo != "" {
+		target, err := q.GetRoleByAlias(ctx, reassignTo)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("reassignment role: %w", e.ErrNotFound)
+			}
+
+			return err
+		}
+
+		if err := q.BumpTokenVersionByRole(ctx, entity.ID); err != nil {
+			return err
+		}
+
+		err = q.ReassignUsersRole(ctx, db.ReassignUsersRoleParams{
+			ToRoleID:   target.ID,
+			FromRoleID: entity.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := q.DeleteRoleAssignments(ctx, entity.ID); err != nil {
+			return err
+		}
+
+		err = q.ReassignClientsRole(ctx, db.ReassignClientsRoleParams{
+			ToRoleID:   target.ID,
+			FromRoleID: entity.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	holders, err := q.CountRoleHolders(ctx, entity.ID)
+	if err != nil {
+		return err
+	}
+
+	if holders.Users > 0 || holders.Clients > 0 {
+		return e.ErrRoleInUse
+	}
+
+	if err := q.DeleteRole(ctx, entity.ID); err != nil {
+		return err
+	}
+
+	if err := resolvePermissions(ctx, q, hierarchy); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// moveRoleFlags takes the default and super flags from the other roles when
+// the role with id gets them. A role nobody holds does not become super, it
+// would leave no super user.
+func moveRoleFlags(ctx context.Context, q *db.Queries, id int32, isDefault bool, isSuper bool) error {
+	if isDefault {
+		if err := q.ClearDefaultRole(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	if isSuper {
+		holders, err := q.CountRoleHolders(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if holders.Users == 0 {
+			return e.ErrLastSuperUser
+		}
+
+		if err := q.ClearSuperRole(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolvePermissions saves the permissions the roles of config get through
+// inheritance: their own permissions and the ones of their ancestors, as
+// stored. The tokens of the users of the roles whose permissions changed are
+// outdated.
+func resolvePermissions(ctx context.Context, q *db.Queries, hierarchy []roles.ResolvedRole) error {
+	entities, err := q.ListRoles(ctx)
+	if err != nil {
+		return err
+	}
+
+	byAlias := make(map[string]db.Role, len(entities))
+	for _, entity := range entities {
+		byAlias[entity.Alias] = entity
+	}
+
+	for _, role := range hierarchy {
+		entity, ok := byAlias[role.Alias]
+		if !ok {
+			continue
+		}
+
+		effective := roles.Mask(entity.OwnPermissions)
+		for _, alias := range role.Ancestors {
+			// A deleted ancestor grants nothing until config declares it
+			// again.
+			if ancestor, ok := byAlias[alias]; ok {
+				effective = effective.Union(ancestor.OwnPermissions)
+			}
+		}
+
+		stored := roles.Mask(entity.Permissions)
+		if stored.Contains(effective) && effective.Contains(stored) {
+			continue
+		}
+
+		err := q.SetRolePermissions(ctx, db.SetRolePermissionsParams{
+			ID:          entity.ID,
+			Permissions: effective,
+		})
+		if err != nil {
+			return fmt.Errorf("role %s: %w", role.Alias, err)
+		}
+
+		if err := q.BumpTokenVersionByRole(ctx, entity.ID); err != nil {
+			return fmt.Errorf("role %s: %w", role.Alias, err)
+		}
+	}
+
+	return nil
+}
+
+func roleModel(entity db.Role) *usecases.RoleModel {
+	return &usecases.RoleModel{
+		ID:                entity.ID,
+		Alias:             entity.Alias,
+		PermissionMask:    roles.Mask(entity.Permissions),
+		OwnPermissionMask: roles.Mask(entity.OwnPermissions),
+		IsDefault:         entity.IsDefault,
+		IsSuper:           entity.IsSuper,
+		Overridden:        entity.Overridden,
+	}
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/repository/db"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/usecases"
+)
+
+func (r *Repository) CreateRefreshToken(
+	ctx context.Context,
+	userID int32,
+	orgID int32,
+	clientID string,
+	familyID string,
+	tokenHash string,
+	expiresAt time.Time,
+) (err error) {
+	const src = "Repository.CreateRefreshToken"
+	log := r.log.With(slog.String("src", src))
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to create refresh token: %w", src, err)
+		}
+	}()
+
+	log.Debug("creating refresh token", slog.Int("user_id", int(userID)))
+
+	return r.queries.CreateRefreshToken(ctx, db.CreateRefreshTokenParams{
+		TokenHash: tokenHash,
+		FamilyID:  familyID,
+		UserID:    userID,
+		OrgID:     orgID,
+		ClientID:  clientID,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// RotateRefreshToken marks the refresh token with oldHash as used and stores
+// newHash in the same family. Presenting an already used token revokes the
+// whole family and returns e.ErrTokenReused. A token presented by another
+// client than the one it was issued to is refused with e.ErrInvalidGrant and
+// left untouched, so that it stays usable by its holder.
+func (r *Repository) RotateRefreshToken(
+	ctx context.Context,
+	oldHash string,
+	newHash string,
+	clientID string,
+	expiresAt time.Time,
+) (refreshToken *usecases.RefreshTokenModel, err error) {
+	const src = "Repository.RotateRefreshToken"
+	log := r.log.With(slog.String("src", src))
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to rotate refresh token: %w", src, err)
+		}
+	}()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.queries.WithTx(tx)
+
+	token, err := q.GetRefreshTokenForUpdate(ctx, oldHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, e.ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	if token.ClientID != clientID {
+		return nil, e.ErrInvalidGrant
+	}
+
+	if token.RevokedAt.Valid {
+		return nil, e.ErrTokenRevoked
+	}
+
+	if token.UsedAt.Valid {
+		log.Warn("refresh token reuse detected, revoking family",
+			slog.Int("user_id", int(token.UserID)),
+			slog.String("family_id", token.FamilyID),
+		)
+
+		if err := q.RevokeRefreshTokenFamily(ctx, token.FamilyID); err != nil {
+			return nil, err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+
+		return nil, e.ErrTokenReused
+	}
+
+	if time.Now().After(token.ExpiresAt) {
+		return nil, e.ErrTokenExpired
+	}
+
+	if err := q.MarkRefreshTokenUsed(ctx, token.ID); err != nil {
+		return nil, err
+	}
+
+	err = q.CreateRefreshToken(ctx, db.CreateRefreshTokenParams{
+		TokenHash: newHash,
+		FamilyID:  token.FamilyID,
+		UserID:    token.UserID,
+		OrgID:     token.OrgID,
+		ClientID:  token.ClientID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &usecases.RefreshTokenModel{
+		UserID:   token.UserID,
+		OrgID:    token.OrgID,
+		ClientID: token.ClientID,
+	}, nil
+}
+
+func (r *Repository) RevokeRefreshTokenFamily(
+	ctx context.Context,
+	tokenHash string,
+) (err error) {
+	const src = "Repository.RevokeRefreshTokenFamily"
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: failed to revoke refresh tokens: %w", src, err)
+		}
+	}()
+
+	return r.queries.RevokeRefreshTokenFamilyByHash(ctx, tokenHash)
+}
@@ -0,0 +1,61 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps revoked token ids in process memory. It is meant for
+// tests and single instance setups.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	revoked map[string]time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		revoked: map[string]time.Time{},
+	}
+}
+
+func (m *MemoryStore) Revoke(
+	ctx context.Context,
+	jti string,
+	expiresAt time.Time,
+) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.revoked[jti] = expiresAt
+	return nil
+}
+
+func (m *MemoryStore) IsRevoked(
+	ctx context.Context,
+	jti string,
+) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.revoked[jti]
+	return ok, nil
+}
+
+func (m *MemoryStore) Purge(
+	ctx context.Context,
+	now time.Time,
+) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var purged int64
+	for jti, expiresAt := range m.revoked {
+		if expiresAt.Before(now) {
+			delete(m.revoked, jti)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
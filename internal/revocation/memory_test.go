@@ -0,0 +1,84 @@
+package revocation
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreRevoke(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	if err := store.Revoke(ctx, "revoked", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+
+	for jti, want := range map[string]bool{"revoked": true, "other": false} {
+		revoked, err := store.IsRevoked(ctx, jti)
+		if err != nil {
+			t.Fatalf("IsRevoked(%q): %v", jti, err)
+		}
+
+		if revoked != want {
+			t.Errorf("IsRevoked(%q) = %v, want %v", jti, revoked, want)
+		}
+	}
+}
+
+func TestMemoryStorePurge(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Now()
+
+	_ = store.Revoke(ctx, "expired", now.Add(-time.Minute))
+	_ = store.Revoke(ctx, "live", now.Add(time.Minute))
+
+	purged, err := store.Purge(ctx, now)
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+
+	if purged != 1 {
+		t.Errorf("Purge() = %d, want 1", purged)
+	}
+
+	if revoked, _ := store.IsRevoked(ctx, "expired"); revoked {
+		t.Error("expired entry was kept")
+	}
+
+	if revoked, _ := store.IsRevoked(ctx, "live"); !revoked {
+		t.Error("live entry was purged")
+	}
+}
+
+func TestRunPurger(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	store := NewMemoryStore()
+
+	_ = store.Revoke(ctx, "expired", time.Now().Add(-time.Minute))
+
+	done := make(chan struct{})
+	go func() {
+		RunPurger(ctx, slog.New(slog.NewTextHandler(io.Discard, nil)), store, time.Millisecond)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if revoked, _ := store.IsRevoked(ctx, "expired"); !revoked {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("expired entry was not purged")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	<-done
+}
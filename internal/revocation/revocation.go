@@ -0,0 +1,58 @@
+package revocation
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+)
+
+// Store is a denylist of token ids. Entries are only needed until the token
+// expires, after which Purge may drop them.
+type Store interface {
+	Revoke(
+		ctx context.Context,
+		jti string,
+		expiresAt time.Time,
+	) (err error)
+
+	IsRevoked(
+		ctx context.Context,
+		jti string,
+	) (revoked bool, err error)
+
+	Purge(
+		ctx context.Context,
+		now time.Time,
+	) (purged int64, err error)
+}
+
+func RunPurger(
+	ctx context.Context,
+	log *slog.Logger,
+	store Store,
+	interval time.Duration,
+) {
+	const src = "revocation.RunPurger"
+	log = log.With(slog.String("src", src))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		purged, err := store.Purge(ctx, time.Now())
+		if err != nil {
+			log.Error("failed to purge revoked tokens", e.SlogErr(err))
+			continue
+		}
+
+		log.Debug("revoked tokens purged", slog.Int64("count", purged))
+	}
+}
@@ -0,0 +1,95 @@
+package roles
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+)
+
+// MaxBit is the highest bit a permission can use. It bounds the size of
+// masks carried in tokens to 128 bytes.
+const MaxBit = 1023
+
+var permissionPattern = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+
+// permKeys maps permission names to their bits. It holds the built-in
+// permissions until LoadCatalog adds the ones declared in config.
+var permKeys = maps.Clone(builtinPermissions)
+
+// PermissionDefinition is a permission with its stable bit position in
+// masks.
+type PermissionDefinition struct {
+	Name string
+	Bit  int
+}
+
+// LoadCatalog makes the permissions declared in config known next to the
+// built-in ones. It is called once at startup, before roles are parsed, and
+// leaves the catalog untouched when defs are invalid.
+func LoadCatalog(defs []PermissionDefinition) error {
+	var problems []string
+
+	keys := maps.Clone(builtinPermissions)
+	owners := make(map[int]string, len(keys)+len(defs))
+	for name, perm := range keys {
+		owners[int(perm)] = name
+	}
+
+	declared := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		if !permissionPattern.MatchString(def.Name) {
+			problems = append(problems, fmt.Sprintf("permission %q: invalid name", def.Name))
+		}
+
+		if def.Bit < 0 || def.Bit > MaxBit {
+			problems = append(problems, fmt.Sprintf("permission %q: bit %d out of range 0..%d", def.Name, def.Bit, MaxBit))
+			continue
+		}
+
+		if declared[def.Name] {
+			problems = append(problems, fmt.Sprintf("permission %q: declared more than once", def.Name))
+			continue
+		}
+		declared[def.Name] = true
+
+		if builtin, ok := builtinPermissions[def.Name]; ok {
+			if int(builtin) != def.Bit {
+				problems = append(problems, fmt.Sprintf("permission %q: built-in permission uses bit %d", def.Name, int(builtin)))
+			}
+			continue
+		}
+
+		if owner, ok := owners[def.Bit]; ok {
+			problems = append(problems, fmt.Sprintf("permission %q: bit %d is used by %q", def.Name, def.Bit, owner))
+			continue
+		}
+
+		owners[def.Bit] = def.Name
+		keys[def.Name] = Permission(def.Bit)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	permKeys = keys
+	return nil
+}
+
+// Catalog lists the known permissions ordered by bit.
+func Catalog() []PermissionDefinition {
+	catalog := make([]PermissionDefinition, 0, len(permKeys))
+	for name, perm := range permKeys {
+		catalog = append(catalog, PermissionDefinition{
+			Name: name,
+			Bit:  int(perm),
+		})
+	}
+
+	slices.SortFunc(catalog, func(a, b PermissionDefinition) int {
+		return a.Bit - b.Bit
+	})
+
+	return catalog
+}
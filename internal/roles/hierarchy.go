@@ -0,0 +1,144 @@
+package roles
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ResolvedRole is a role with the permissions it gets through inheritance.
+type ResolvedRole struct {
+	Alias string
+	// Inherits lists the roles the role declares to inherit from.
+	Inherits []string
+	// Ancestors lists every role inherited directly or transitively.
+	Ancestors   []string
+	Permissions Mask
+	// Effective is the union of the role permissions and the ones of its
+	// ancestors. It is the mask saved for the role.
+	Effective Mask
+	Default   bool
+	Super     bool
+}
+
+// Resolve computes the effective permissions of every role, in the order of
+// defs. Definitions that do not pass Validate are an error.
+func Resolve(defs []Definition) ([]ResolvedRole, error) {
+	if err := Validate(defs); err != nil {
+		return nil, err
+	}
+
+	byAlias := make(map[string]*Definition, len(defs))
+	for i := range defs {
+		byAlias[defs[i].Alias] = &defs[i]
+	}
+
+	resolved := make([]ResolvedRole, 0, len(defs))
+	for _, def := range defs {
+		own, err := ParsePermissions(def.Permissions)
+		if err != nil {
+			return nil, fmt.Errorf("role %q: %w", def.Alias, err)
+		}
+
+		ancestors := ancestorsOf(def.Alias, byAlias)
+		effective := own
+		for _, alias := range ancestors {
+			mask, err := ParsePermissions(byAlias[alias].Permissions)
+			if err != nil {
+				return nil, fmt.Errorf("role %q: %w", alias, err)
+			}
+
+			effective = effective.Union(mask)
+		}
+
+		resolved = append(resolved, ResolvedRole{
+			Alias:       def.Alias,
+			Inherits:    slices.Clone(def.Inherits),
+			Ancestors:   ancestors,
+			Permissions: own,
+			Effective:   effective,
+			Default:     def.Default,
+			Super:       def.Super,
+		})
+	}
+
+	return resolved, nil
+}
+
+// ancestorsOf walks the parents of alias breadth first. Every parent must
+// be defined.
+func ancestorsOf(alias string, byAlias map[string]*Definition) []string {
+	var ancestors []string
+	seen := map[string]bool{alias: true}
+
+	queue := slices.Clone(byAlias[alias].Inherits)
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+
+		if seen[parent] {
+			continue
+		}
+		seen[parent] = true
+		ancestors = append(ancestors, parent)
+
+		queue = append(queue, byAlias[parent].Inherits...)
+	}
+
+	slices.Sort(ancestors)
+	return ancestors
+}
+
+// findCycles reports each inheritance cycle once, as the path of aliases
+// leading back to its first role.
+func findCycles(defs []Definition) []string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+
+	byAlias := make(map[string]*Definition, len(defs))
+	for i := range defs {
+		byAlias[defs[i].Alias] = &defs[i]
+	}
+
+	var (
+		problems []string
+		path     []string
+		visit    func(alias string)
+	)
+
+	state := make(map[string]int, len(defs))
+	visit = func(alias string) {
+		def, ok := byAlias[alias]
+		if !ok {
+			return
+		}
+
+		state[alias] = visiting
+		path = append(path, alias)
+
+		for _, parent := range def.Inherits {
+			switch state[parent] {
+			case visiting:
+				start := slices.Index(path, parent)
+				cycle := append(slices.Clone(path[start:]), parent)
+				problems = append(problems, fmt.Sprintf("role %q: inheritance cycle %s", parent, strings.Join(cycle, " -> ")))
+			case unvisited:
+				visit(parent)
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[alias] = done
+	}
+
+	for _, def := range defs {
+		if state[def.Alias] == unvisited {
+			visit(def.Alias)
+		}
+	}
+
+	return problems
+}
@@ -0,0 +1,121 @@
+package roles
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	defs := []Definition{
+		{Alias: "admin", Permissions: []string{"manage_roles"}, Inherits: []string{"moderator"}, Super: true},
+		{Alias: "moderator", Permissions: []string{"ban_users"}, Inherits: []string{"user", "viewer"}},
+		{Alias: "viewer", Permissions: []string{"see_profiles"}, Inherits: []string{"user"}},
+		{Alias: "user", Default: true},
+	}
+
+	resolved, err := Resolve(defs)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	if len(resolved) != len(defs) {
+		t.Fatalf("Resolve returned %d roles, want %d", len(resolved), len(defs))
+	}
+
+	for i, def := range defs {
+		if resolved[i].Alias != def.Alias {
+			t.Errorf("role %d is %q, want %q in config order", i, resolved[i].Alias, def.Alias)
+		}
+	}
+
+	admin := resolved[0]
+	if want := []string{"moderator", "user", "viewer"}; !slices.Equal(admin.Ancestors, want) {
+		t.Errorf("admin ancestors = %v, want %v", admin.Ancestors, want)
+	}
+
+	if want := []string{"moderator"}; !slices.Equal(admin.Inherits, want) {
+		t.Errorf("admin inherits = %v, want %v", admin.Inherits, want)
+	}
+
+	if !admin.Permissions.Contains(NewMask(CanManageRoles)) || admin.Permissions.Contains(NewMask(CanBanUsers)) {
+		t.Errorf("admin own permissions = %v", PermissionNames(admin.Permissions))
+	}
+
+	effective := NewMask(CanManageRoles, CanBanUsers, CanSeeProfiles)
+	if !admin.Effective.Contains(effective) || !effective.Contains(admin.Effective) {
+		t.Errorf("admin effective permissions = %v", PermissionNames(admin.Effective))
+	}
+
+	user := resolved[3]
+	if len(user.Ancestors) != 0 || len(user.Effective.Bits()) != 0 {
+		t.Errorf("user resolved to ancestors %v and permissions %v", user.Ancestors, PermissionNames(user.Effective))
+	}
+}
+
+func TestResolveRejectsCycles(t *testing.T) {
+	defs := []Definition{
+		{Alias: "a", Inherits: []string{"b"}, Super: true},
+		{Alias: "b", Inherits: []string{"c"}},
+		{Alias: "c", Inherits: []string{"a"}},
+		{Alias: "d", Inherits: []string{"d"}, Default: true},
+	}
+
+	_, err := Resolve(defs)
+
+	var validation *ValidationError
+	if !errors.As(err, &validation) {
+		t.Fatalf("Resolve error = %v, want a ValidationError", err)
+	}
+
+	joined := strings.Join(validation.Problems, "; ")
+	for _, cycle := range []string{"a -> b -> c -> a", "d -> d"} {
+		if !strings.Contains(joined, cycle) {
+			t.Errorf("problems %q do not report cycle %s", joined, cycle)
+		}
+	}
+}
+
+func TestFindCycles(t *testing.T) {
+	tests := []struct {
+		name string
+		defs []Definition
+		want []string
+	}{
+		{
+			name: "no cycle",
+			defs: []Definition{
+				{Alias: "a", Inherits: []string{"b", "c"}},
+				{Alias: "b", Inherits: []string{"c"}},
+				{Alias: "c"},
+			},
+		},
+		{
+			name: "self inheritance",
+			defs: []Definition{{Alias: "a", Inherits: []string{"a"}}},
+			want: []string{`role "a": inheritance cycle a -> a`},
+		},
+		{
+			name: "cycle reported once",
+			defs: []Definition{
+				{Alias: "a", Inherits: []string{"b"}},
+				{Alias: "b", Inherits: []string{"a"}},
+				{Alias: "c", Inherits: []string{"a"}},
+			},
+			want: []string{`role "a": inheritance cycle a -> b -> a`},
+		},
+		{
+			name: "unknown parent",
+			defs: []Definition{{Alias: "a", Inherits: []string{"missing"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findCycles(tt.defs); !slices.Equal(got, tt.want) {
+				t.Errorf("findCycles() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,29 +4,28 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"slices"
 )
 
-var permKeys = map[string]Permission{
-	"crud_personal_issues":      CanCRUDPersonalIssues,
-	"comment_personal_issues":   CanCommentPersonalIssues,
-	"crud_personal_comments":    CanCRUDPersonalComments,
-	"close_personal_issues":     CanClosePersonalIssues,
-	"update_user_role":          CanUpdateUserRole,
-	"comment_external_issues":   CanCommentExternalIssues,
-	"close_external_issues":     CanCloseExternalIssues,
-	"see_issues_list":           CanSeeIssuesList,
-	"collect_issues_statistics": CanCollectIssuesStatistics,
-	"see_profiles":              CanSeeProfiles,
+type RoleStorage interface {
+	// SaveRoles upserts the roles at once and outdates the tokens carrying
+	// permissions that changed.
+	SaveRoles(
+		ctx context.Context,
+		roles []ResolvedRole,
+	) (err error)
 }
 
-type RoleStorage interface {
-	UpsertRole(
+type PermissionStorage interface {
+	ListPermissions(
+		ctx context.Context,
+	) (permissions []PermissionDefinition, err error)
+
+	CreatePermission(
 		ctx context.Context,
-		alias string,
-		mask int64,
-		isDefault bool,
-		isSuper bool,
-	) (id int32, err error)
+		name string,
+		bit int,
+	) (err error)
 }
 
 type RoleConfig struct {
@@ -35,57 +34,113 @@ type RoleConfig struct {
 }
 
 type RolesManager struct {
-	log     *slog.Logger
-	storage RoleStorage
+	log         *slog.Logger
+	storage     RoleStorage
+	permissions PermissionStorage
+	hierarchy   []ResolvedRole
 }
 
 func NewManager(
 	log *slog.Logger,
 	storage RoleStorage,
+	permissions PermissionStorage,
 ) *RolesManager {
 	return &RolesManager{
-		log:     log,
-		storage: storage,
+		log:         log,
+		storage:     storage,
+		permissions: permissions,
 	}
 }
 
-func (r *RolesManager) CreateRole(
-	ctx context.Context,
-	alias string,
-	permissions []string,
-	isDefault bool,
-	isSuper bool,
-) (err error) {
-	const src = "RolesManager.CreateRole"
+// SyncPermissions saves the loaded catalog. A permission never changes its
+// bit and a bit is never reused by another permission, even after the one
+// using it was removed from config, since stored masks would change meaning.
+func (r *RolesManager) SyncPermissions(ctx context.Context) (err error) {
+	const src = "RolesManager.SyncPermissions"
 	log := r.log.With(slog.String("src", src))
 
-	mask, countPerms := r.maskFromPermArray(permissions)
-
-	// ignoring role id
-	_, err = r.storage.UpsertRole(ctx, alias, mask, isDefault, isSuper)
+	stored, err := r.permissions.ListPermissions(ctx)
 	if err != nil {
-		return fmt.Errorf("%s: failed to save %s role: %w", src, alias, err)
+		return fmt.Errorf("%s: failed to list permissions: %w", src, err)
+	}
+
+	bitsByName := make(map[string]int, len(stored))
+	namesByBit := make(map[int]string, len(stored))
+	for _, perm := range stored {
+		bitsByName[perm.Name] = perm.Bit
+		namesByBit[perm.Bit] = perm.Name
 	}
 
-	log.Info("role indexed",
-		slog.String("alias", alias),
-		slog.Int("permissions_granted", countPerms),
-		slog.Int("total_permissions", len(permissions)),
+	var (
+		problems []string
+		created  []PermissionDefinition
 	)
 
+	for _, perm := range Catalog() {
+		if bit, ok := bitsByName[perm.Name]; ok {
+			if bit != perm.Bit {
+				problems = append(problems, fmt.Sprintf("permission %q: moved from bit %d to %d", perm.Name, bit, perm.Bit))
+			}
+			continue
+		}
+
+		if name, ok := namesByBit[perm.Bit]; ok {
+			problems = append(problems, fmt.Sprintf("permission %q: bit %d was used by %q", perm.Name, perm.Bit, name))
+			continue
+		}
+
+		created = append(created, perm)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s: %w", src, &ValidationError{Problems: problems})
+	}
+
+	for _, perm := range created {
+		if err := r.permissions.CreatePermission(ctx, perm.Name, perm.Bit); err != nil {
+			return fmt.Errorf("%s: failed to save %s permission: %w", src, perm.Name, err)
+		}
+
+		log.Info("permission indexed",
+			slog.String("name", perm.Name),
+			slog.Int("bit", perm.Bit),
+		)
+	}
+
 	return nil
 }
 
-func (r *RolesManager) maskFromPermArray(permissions []string) (mask int64, cnt int) {
-	mask = 0
-	cnt = 0
+// SyncRoles saves the roles declared in config with the permissions they get
+// through inheritance and keeps the resolved hierarchy.
+func (r *RolesManager) SyncRoles(ctx context.Context, defs []Definition) (err error) {
+	const src = "RolesManager.SyncRoles"
+	log := r.log.With(slog.String("src", src))
 
-	for _, key := range permissions {
-		if perm, exists := permKeys[key]; exists {
-			mask = AddPermission(mask, perm)
-			cnt += 1
-		}
+	resolved, err := Resolve(defs)
+	if err != nil {
+		return fmt.Errorf("%s: %w", src, err)
 	}
 
-	return mask, cnt
+	// Roles inheriting from each other are saved together, a failure leaves
+	// none of them changed.
+	err = r.storage.SaveRoles(ctx, resolved)
+	if err != nil {
+		return fmt.Errorf("%s: %w", src, err)
+	}
+
+	for _, role := range resolved {
+		log.Info("role indexed",
+			slog.String("alias", role.Alias),
+			slog.Any("inherits", role.Inherits),
+			slog.Int("permissions_granted", len(role.Effective.Bits())),
+		)
+	}
+
+	r.hierarchy = resolved
+	return nil
+}
+
+// Hierarchy returns the roles resolved by the last SyncRoles in config order.
+func (r *RolesManager) Hierarchy() []ResolvedRole {
+	return slices.Clone(r.hierarchy)
 }
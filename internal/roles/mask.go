@@ -0,0 +1,148 @@
+package roles
+
+import (
+	"encoding/base64"
+	"errors"
+)
+
+var ErrInvalidMask = errors.New("invalid permissions mask")
+
+// Mask is a set of permissions of any size. Permission n is bit n%8 of byte
+// n/8, so the first 63 permissions keep the bits they had in the int64 masks
+// used before. Trailing zero bytes are insignificant.
+type Mask []byte
+
+func NewMask(permissions ...Permission) Mask {
+	var mask Mask
+	return mask.With(permissions...)
+}
+
+// MaskFromInt64 converts a mask of the int64 format tokens and roles used
+// to carry.
+func MaskFromInt64(legacy int64) Mask {
+	mask := make(Mask, 8)
+	for i := range mask {
+		mask[i] = byte(legacy >> (8 * i))
+	}
+
+	return mask.trim()
+}
+
+func (m Mask) Has(permission Permission) bool {
+	i := int(permission) / 8
+	return permission >= 0 && i < len(m) && m[i]&(1<<(permission%8)) != 0
+}
+
+// With returns a copy of m with permissions added.
+func (m Mask) With(permissions ...Permission) Mask {
+	mask := append(Mask(nil), m...)
+	for _, perm := range permissions {
+		if perm < 0 {
+			continue
+		}
+
+		i := int(perm) / 8
+		for len(mask) <= i {
+			mask = append(mask, 0)
+		}
+
+		mask[i] |= 1 << (perm % 8)
+	}
+
+	return mask
+}
+
+// Contains reports whether m has every permission of other.
+func (m Mask) Contains(other Mask) bool {
+	for i, b := range other {
+		var own byte
+		if i < len(m) {
+			own = m[i]
+		}
+
+		if b&^own != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Union returns the permissions of both m and other.
+func (m Mask) Union(other Mask) Mask {
+	if len(other) > len(m) {
+		m, other = other, m
+	}
+
+	mask := append(Mask(nil), m...)
+	for i, b := range other {
+		mask[i] |= b
+	}
+
+	return mask
+}
+
+// Bits lists the set bits in ascending order.
+func (m Mask) Bits() []int {
+	var bits []int
+	for i, b := range m {
+		for j := 0; j < 8; j++ {
+			if b&(1<<j) != 0 {
+				bits = append(bits, 8*i+j)
+			}
+		}
+	}
+
+	return bits
+}
+
+// Int64 converts m to the int64 format. It fails when m has bits above 62.
+func (m Mask) Int64() (int64, bool) {
+	m = m.trim()
+	if len(m) > 8 || (len(m) == 8 && m[7]&0x80 != 0) {
+		return 0, false
+	}
+
+	var legacy int64
+	for i, b := range m {
+		legacy |= int64(b) << (8 * i)
+	}
+
+	return legacy, true
+}
+
+// String encodes m as unpadded base64url, the form masks have in tokens.
+func (m Mask) String() string {
+	return base64.RawURLEncoding.EncodeToString(m.trim())
+}
+
+func ParseMask(s string) (Mask, error) {
+	mask, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidMask
+	}
+
+	return Mask(mask).trim(), nil
+}
+
+func (m Mask) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *Mask) UnmarshalText(text []byte) error {
+	mask, err := ParseMask(string(text))
+	if err != nil {
+		return err
+	}
+
+	*m = mask
+	return nil
+}
+
+func (m Mask) trim() Mask {
+	for len(m) > 0 && m[len(m)-1] == 0 {
+		m = m[:len(m)-1]
+	}
+
+	return m
+}
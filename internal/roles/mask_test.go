@@ -0,0 +1,115 @@
+package roles
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestMaskTextRoundTrip(t *testing.T) {
+	masks := []Mask{
+		nil,
+		NewMask(0),
+		NewMask(CanUpdateUserRole, CanManageRoles),
+		NewMask(63),
+		NewMask(0, 200),
+	}
+
+	for _, mask := range masks {
+		text, err := mask.MarshalText()
+		if err != nil {
+			t.Fatalf("MarshalText(%v): %v", mask, err)
+		}
+
+		var parsed Mask
+		if err := parsed.UnmarshalText(text); err != nil {
+			t.Fatalf("UnmarshalText(%q): %v", text, err)
+		}
+
+		if !bytes.Equal(parsed, mask) {
+			t.Errorf("round trip of %v gave %v", mask, parsed)
+		}
+	}
+}
+
+func TestMaskTrailingZeroBytes(t *testing.T) {
+	mask := Mask{0x10, 0, 0}
+
+	if got, want := mask.String(), NewMask(CanUpdateUserRole).String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	parsed, err := ParseMask(base64.RawURLEncoding.EncodeToString(mask))
+	if err != nil {
+		t.Fatalf("ParseMask: %v", err)
+	}
+
+	if !bytes.Equal(parsed, Mask{0x10}) {
+		t.Errorf("ParseMask kept trailing zero bytes: %v", parsed)
+	}
+}
+
+func TestParseMaskInvalid(t *testing.T) {
+	if _, err := ParseMask("not base64!"); !errors.Is(err, ErrInvalidMask) {
+		t.Errorf("ParseMask error = %v, want %v", err, ErrInvalidMask)
+	}
+
+	var claims struct {
+		Perms Mask `json:"perms"`
+	}
+	if err := json.Unmarshal([]byte(`{"perms":"%%"}`), &claims); err == nil {
+		t.Error("invalid mask was unmarshalled")
+	}
+}
+
+func TestMaskInt64(t *testing.T) {
+	legacy := []int64{0, 1, 1 << 4, 1<<9 | 1<<12, 1<<62 | 1}
+
+	for _, value := range legacy {
+		mask := MaskFromInt64(value)
+
+		for bit := 0; bit < 63; bit++ {
+			if got, want := mask.Has(Permission(bit)), value&(1<<bit) != 0; got != want {
+				t.Errorf("MaskFromInt64(%d).Has(%d) = %v, want %v", value, bit, got, want)
+			}
+		}
+
+		back, ok := mask.Int64()
+		if !ok || back != value {
+			t.Errorf("MaskFromInt64(%d).Int64() = %d, %v", value, back, ok)
+		}
+	}
+}
+
+func TestMaskInt64Overflow(t *testing.T) {
+	for _, bit := range []Permission{63, 64, 200} {
+		if _, ok := NewMask(bit).Int64(); ok {
+			t.Errorf("mask with bit %d converted to int64", bit)
+		}
+	}
+}
+
+func TestMaskContains(t *testing.T) {
+	tests := []struct {
+		name  string
+		m     Mask
+		other Mask
+		want  bool
+	}{
+		{"empty other", NewMask(1), nil, true},
+		{"subset", NewMask(1, 2, 70), NewMask(2, 70), true},
+		{"missing bit", NewMask(1), NewMask(1, 2), false},
+		{"longer other", NewMask(1), NewMask(1, 70), false},
+		{"longer other with zero bytes", NewMask(1), Mask{0x02, 0, 0}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.Contains(tt.other); got != tt.want {
+				t.Errorf("Contains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,28 +1,77 @@
 package roles
 
-type Permission int64
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Permission is the bit a permission has in masks.
+type Permission int
 
+// Built-in permissions are the ones jwt-auth checks itself. Their bits are
+// fixed, permissions of the products using jwt-auth are declared in config.
 const (
-	CanCRUDPersonalIssues = 1 << iota
-	CanCommentPersonalIssues
-	CanCRUDPersonalComments
-	CanClosePersonalIssues
+	CanUpdateUserRole Permission = 4
+	CanSeeProfiles    Permission = 9
+	CanManageKeys     Permission = 10
+	CanBanUsers       Permission = 11
+	CanManageRoles    Permission = 12
+)
 
-	CanUpdateUserRole
+var builtinPermissions = map[string]Permission{
+	"update_user_role": CanUpdateUserRole,
+	"see_profiles":     CanSeeProfiles,
+	"manage_keys":      CanManageKeys,
+	"ban_users":        CanBanUsers,
+	"manage_roles":     CanManageRoles,
+}
 
-	CanCommentExternalIssues
-	CanCloseExternalIssues
+var ErrUnknownPermission = errors.New("unknown permission")
 
-	CanSeeIssuesList
-	CanCollectIssuesStatistics
+func HasPermission(mask Mask, permission Permission) bool {
+	return mask.Has(permission)
+}
 
-	CanSeeProfiles
-)
+func AddPermission(mask Mask, permission Permission) Mask {
+	return mask.With(permission)
+}
+
+// PermissionNames decodes mask into the names permissions have in config.
+func PermissionNames(mask Mask) []string {
+	names := make([]string, 0, len(permKeys))
+	for name, perm := range permKeys {
+		if HasPermission(mask, perm) {
+			names = append(names, name)
+		}
+	}
 
-func HasPermission(mask int64, permission Permission) bool {
-	return mask&int64(permission) != 0
+	slices.Sort(names)
+	return names
 }
 
-func AddPermission(mask int64, permission Permission) int64 {
-	return mask | int64(permission)
+// ParsePermissions builds the mask of the named permissions. An unknown name
+// is an error.
+func ParsePermissions(names []string) (Mask, error) {
+	var (
+		mask    Mask
+		unknown []string
+	)
+
+	for _, name := range names {
+		perm, exists := permKeys[name]
+		if !exists {
+			unknown = append(unknown, name)
+			continue
+		}
+
+		mask = AddPermission(mask, perm)
+	}
+
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPermission, strings.Join(unknown, ", "))
+	}
+
+	return mask, nil
 }
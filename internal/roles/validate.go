@@ -0,0 +1,121 @@
+package roles
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// aliasPattern matches valid role aliases.
+var aliasPattern = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+// Definition is a role as declared in config.
+type Definition struct {
+	Alias       string
+	Permissions []string
+	Inherits    []string
+	Default     bool
+	Super       bool
+}
+
+// ValidationError lists every problem found in the permissions and roles
+// declared in config.
+type ValidationError struct {
+	Problems []string
+}
+
+func (v *ValidationError) Error() string {
+	return "invalid roles config: " + strings.Join(v.Problems, "; ")
+}
+
+// Validate checks role definitions before anything is saved: aliases must be
+// valid and unique, permissions known and listed once, inherited roles
+// defined and free of cycles, and exactly one role must be default and
+// exactly one super, never the same one.
+func Validate(defs []Definition) error {
+	var (
+		problems []string
+		defaults []string
+		supers   []string
+	)
+
+	defined := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		defined[def.Alias] = true
+	}
+
+	seen := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		if !aliasPattern.MatchString(def.Alias) {
+			problems = append(problems, fmt.Sprintf("role %q: invalid alias", def.Alias))
+		}
+
+		if seen[def.Alias] {
+			problems = append(problems, fmt.Sprintf("role %q: declared more than once", def.Alias))
+		}
+		seen[def.Alias] = true
+
+		var unknown []string
+		listed := make(map[string]bool, len(def.Permissions))
+		for _, name := range def.Permissions {
+			if _, exists := permKeys[name]; !exists {
+				unknown = append(unknown, fmt.Sprintf("%q", name))
+				continue
+			}
+
+			if listed[name] {
+				problems = append(problems, fmt.Sprintf("role %q: permission %q listed more than once", def.Alias, name))
+			}
+			listed[name] = true
+		}
+
+		if len(unknown) > 0 {
+			problems = append(problems, fmt.Sprintf("role %q: unknown permissions %s", def.Alias, strings.Join(unknown, ", ")))
+		}
+
+		inherited := make(map[string]bool, len(def.Inherits))
+		for _, parent := range def.Inherits {
+			if !defined[parent] {
+				problems = append(problems, fmt.Sprintf("role %q: inherits unknown role %q", def.Alias, parent))
+			}
+
+			if inherited[parent] {
+				problems = append(problems, fmt.Sprintf("role %q: role %q inherited more than once", def.Alias, parent))
+			}
+			inherited[parent] = true
+		}
+
+		if def.Default && def.Super {
+			problems = append(problems, fmt.Sprintf("role %q: cannot be both default and super", def.Alias))
+		}
+
+		if def.Default {
+			defaults = append(defaults, def.Alias)
+		}
+
+		if def.Super {
+			supers = append(supers, def.Alias)
+		}
+	}
+
+	problems = append(problems, findCycles(defs)...)
+	problems = append(problems, checkUnique("default", defaults)...)
+	problems = append(problems, checkUnique("super", supers)...)
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+func checkUnique(flag string, aliases []string) []string {
+	switch len(aliases) {
+	case 0:
+		return []string{fmt.Sprintf("no %s role", flag)}
+	case 1:
+		return nil
+	default:
+		return []string{fmt.Sprintf("several %s roles: %s", flag, strings.Join(aliases, ", "))}
+	}
+}
@@ -0,0 +1,116 @@
+package tokenizer
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/handlers"
+)
+
+var (
+	ErrTokenNotYetValid = errors.New("token is not valid yet")
+	ErrInvalidIssuer    = errors.New("invalid token issuer")
+	ErrInvalidAudience  = errors.New("invalid token audience")
+)
+
+// registeredClaims are the RFC 7519 claims. Unlike jwt.StandardClaims it
+// allows several audiences and leaves validation to the Tokenizer, which
+// applies the configured clock skew leeway.
+type registeredClaims struct {
+	Issuer    string   `json:"iss,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	Audience  audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	ID        string   `json:"jti,omitempty"`
+}
+
+type tokenClaims struct {
+	registeredClaims
+	handlers.TokenData
+}
+
+// Valid is called by the jwt package while parsing. Claims are checked
+// afterwards by Tokenizer.validate or Tokenizer.validateAnyAudience.
+func (c *tokenClaims) Valid() error {
+	return nil
+}
+
+// idTokenClaims are the OpenID Connect ID token claims. ID tokens are only
+// issued, never parsed by the Tokenizer.
+type idTokenClaims struct {
+	registeredClaims
+	Nonce             string `json:"nonce,omitempty"`
+	AuthTime          int64  `json:"auth_time"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+}
+
+func (c *idTokenClaims) Valid() error {
+	return nil
+}
+
+// audience is marshalled as a single string when it holds one value and
+// accepts both forms allowed by RFC 7519 when unmarshalled.
+type audience []string
+
+func (a audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+
+	return json.Marshal([]string(a))
+}
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+
+	*a = multiple
+	return nil
+}
+
+func (t *Tokenizer) validate(claims *registeredClaims, now time.Time) error {
+	if err := t.validateAnyAudience(claims, now); err != nil {
+		return err
+	}
+
+	if !slices.Contains(claims.Audience, t.audience) {
+		return ErrInvalidAudience
+	}
+
+	return nil
+}
+
+// validateAnyAudience checks the claims as validate does but accepts tokens
+// issued to other audiences than this service.
+func (t *Tokenizer) validateAnyAudience(claims *registeredClaims, now time.Time) error {
+	if claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(t.leeway)) {
+		return e.ErrTokenExpired
+	}
+
+	if claims.NotBefore != 0 && now.Before(time.Unix(claims.NotBefore, 0).Add(-t.leeway)) {
+		return ErrTokenNotYetValid
+	}
+
+	if claims.IssuedAt != 0 && now.Before(time.Unix(claims.IssuedAt, 0).Add(-t.leeway)) {
+		return ErrTokenNotYetValid
+	}
+
+	if claims.Issuer != t.issuer {
+		return ErrInvalidIssuer
+	}
+
+	return nil
+}
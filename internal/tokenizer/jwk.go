@@ -0,0 +1,71 @@
+package tokenizer
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/handlers"
+)
+
+// jwk describes the public part of the key. Symmetric keys have no public
+// part and are reported with ok set to false.
+func (k *Key) jwk() (key handlers.JWK, ok bool) {
+	key = handlers.JWK{
+		Alg: k.method.Alg(),
+		Use: "sig",
+	}
+
+	switch public := k.publicKey.(type) {
+	case *rsa.PublicKey:
+		key.Kty = "RSA"
+		key.N = encodeSegment(public.N.Bytes())
+		key.E = encodeSegment(big.NewInt(int64(public.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (public.Curve.Params().BitSize + 7) / 8
+		key.Kty = "EC"
+		key.Crv = public.Curve.Params().Name
+		key.X = encodeSegment(public.X.FillBytes(make([]byte, size)))
+		key.Y = encodeSegment(public.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		key.Kty = "OKP"
+		key.Crv = "Ed25519"
+		key.X = encodeSegment(public)
+	default:
+		return handlers.JWK{}, false
+	}
+
+	key.Kid = k.id
+	return key, true
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint used as the key id.
+func (k *Key) thumbprint() string {
+	var canonical string
+
+	if secret, ok := k.publicKey.([]byte); ok {
+		canonical = fmt.Sprintf(`{"k":"%s","kty":"oct"}`, encodeSegment(secret))
+	} else {
+		key, _ := k.jwk()
+
+		switch key.Kty {
+		case "RSA":
+			canonical = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, key.E, key.N)
+		case "EC":
+			canonical = fmt.Sprintf(`{"crv":"%s","kty":"EC","x":"%s","y":"%s"}`, key.Crv, key.X, key.Y)
+		case "OKP":
+			canonical = fmt.Sprintf(`{"crv":"%s","kty":"OKP","x":"%s"}`, key.Crv, key.X)
+		}
+	}
+
+	sum := sha256.Sum256([]byte(canonical))
+	return encodeSegment(sum[:])
+}
+
+func encodeSegment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -0,0 +1,434 @@
+package tokenizer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+	"github.com/golang-jwt/jwt"
+)
+
+const rsaKeyBits = 2048
+
+const (
+	// unknownKeyReloadInterval limits how often a token signed with an
+	// unknown key makes the keyring reload, so forged key ids can't turn
+	// into a storage query per request.
+	unknownKeyReloadInterval = 10 * time.Second
+	unknownKeyReloadTimeout  = 5 * time.Second
+)
+
+var (
+	ErrUnknownKey = errors.New("unknown key id")
+)
+
+type StoredKey struct {
+	ID          string
+	Algorithm   string
+	PrivateKey  []byte
+	CreatedAt   time.Time
+	RetiredAt   *time.Time
+	ActivatesAt time.Time
+}
+
+type KeyStorage interface {
+	ListSigningKeys(
+		ctx context.Context,
+	) (keys []StoredKey, err error)
+
+	// CreateSigningKey stores key as the one to sign with from its
+	// ActivatesAt on and retires every previously active key at that time.
+	CreateSigningKey(
+		ctx context.Context,
+		key StoredKey,
+	) (err error)
+
+	DeleteSigningKeysRetiredBefore(
+		ctx context.Context,
+		before time.Time,
+	) (err error)
+}
+
+// Keyring keeps one active signing key, the rotated key waiting to become
+// active and the retired keys that are still accepted for verification until
+// their grace period ends.
+type Keyring struct {
+	log              *slog.Logger
+	storage          KeyStorage
+	algorithm        string
+	rotationInterval time.Duration
+	gracePeriod      time.Duration
+	publishDelay     time.Duration
+
+	mu         sync.RWMutex
+	signers    []signer
+	latest     time.Time
+	keys       map[string]*Key
+	reloadedAt time.Time
+}
+
+// signer is a key along with the time it starts signing.
+type signer struct {
+	key  *Key
+	from time.Time
+}
+
+// NewKeyring creates a keyring. A rotated key is only published for the
+// publishDelay before it starts signing, so every instance and verifier
+// caching the key set knows it by then.
+func NewKeyring(
+	log *slog.Logger,
+	storage KeyStorage,
+	algorithm string,
+	rotationInterval time.Duration,
+	gracePeriod time.Duration,
+	publishDelay time.Duration,
+) *Keyring {
+	return &Keyring{
+		log:              log,
+		storage:          storage,
+		algorithm:        algorithm,
+		rotationInterval: rotationInterval,
+		gracePeriod:      gracePeriod,
+		publishDelay:     publishDelay,
+		keys:             map[string]*Key{},
+	}
+}
+
+// Init loads the keys from storage. When storage has no active key, seed is
+// stored as the first one, or a fresh key is generated if seed is nil.
+func (k *Keyring) Init(ctx context.Context, seed *Key) (err error) {
+	const src = "Keyring.Init"
+
+	if err := k.reload(ctx); err != nil {
+		return fmt.Errorf("%s: failed to load keys: %w", src, err)
+	}
+
+	if active := k.Active(); active != nil {
+		if active.Algorithm() == k.algorithm {
+			// The stored keys win, importing seed again would undo the
+			// rotations done since it was first stored.
+			if seed == nil {
+				return nil
+			}
+
+			if _, ok := k.Lookup(seed.ID()); !ok {
+				k.log.Warn("configured signing key is ignored, the stored keys are used; rotate to replace them",
+					slog.String("src", src),
+					slog.String("kid", active.ID()),
+				)
+			}
+
+			return nil
+		}
+
+		_, err = k.Rotate(ctx)
+		return err
+	}
+
+	if seed == nil || !seed.CanSign() {
+		seed, err = GenerateKey(k.algorithm)
+		if err != nil {
+			return fmt.Errorf("%s: failed to generate key: %w", src, err)
+		}
+	}
+
+	// Nothing verifies tokens of this keyring yet, the first key signs at
+	// once.
+	if err := k.store(ctx, seed, time.Now()); err != nil {
+		return fmt.Errorf("%s: failed to store key: %w", src, err)
+	}
+
+	return nil
+}
+
+// Rotate generates a new key. It is published at once but only starts
+// signing after the publish delay, the previous key keeps signing until then
+// and stays valid for verification during the grace period after.
+func (k *Keyring) Rotate(ctx context.Context) (kid string, err error) {
+	const src = "Keyring.Rotate"
+
+	key, err := GenerateKey(k.algorithm)
+	if err != nil {
+		return "", fmt.Errorf("%s: failed to generate key: %w", src, err)
+	}
+
+	activatesAt := time.Now().Add(k.publishDelay)
+	if err := k.store(ctx, key, activatesAt); err != nil {
+		return "", fmt.Errorf("%s: failed to store key: %w", src, err)
+	}
+
+	k.log.Info("signing key published",
+		slog.String("kid", key.ID()),
+		slog.Time("activates_at", activatesAt),
+	)
+
+	return key.ID(), nil
+}
+
+// Run periodically reloads keys, so rotations done by other instances are
+// picked up, rotates once the newest key, pending or active, outlives the
+// rotation interval and deletes keys whose grace period has ended.
+func (k *Keyring) Run(ctx context.Context, checkInterval time.Duration) {
+	const src = "Keyring.Run"
+	log := k.log.With(slog.String("src", src))
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := k.reload(ctx); err != nil {
+			log.Error("failed to reload keys", e.SlogErr(err))
+			continue
+		}
+
+		k.mu.RLock()
+		due := k.rotationInterval > 0 && time.Since(k.latest) >= k.rotationInterval
+		k.mu.RUnlock()
+
+		if due {
+			if _, err := k.Rotate(ctx); err != nil {
+				log.Error("scheduled rotation failed", e.SlogErr(err))
+			}
+		}
+
+		err := k.storage.DeleteSigningKeysRetiredBefore(ctx, time.Now().Add(-k.gracePeriod))
+		if err != nil {
+			log.Error("failed to delete expired keys", e.SlogErr(err))
+		}
+	}
+}
+
+// Active returns the key to sign with: the newest one whose activation time
+// has come.
+func (k *Keyring) Active() *Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	now := time.Now()
+	for i := len(k.signers) - 1; i >= 0; i-- {
+		if !k.signers[i].from.After(now) {
+			return k.signers[i].key
+		}
+	}
+
+	return nil
+}
+
+func (k *Keyring) Lookup(kid string) (*Key, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+// Resolve looks a key up and reloads the keyring when kid is unknown, since
+// another instance may have rotated since the last reload.
+func (k *Keyring) Resolve(kid string) (*Key, bool) {
+	if key, ok := k.Lookup(kid); ok {
+		return key, true
+	}
+
+	// The slot is claimed before reloading, so concurrent requests with the
+	// same unknown kid reload once.
+	k.mu.Lock()
+	if time.Since(k.reloadedAt) < unknownKeyReloadInterval {
+		k.mu.Unlock()
+		return nil, false
+	}
+	k.reloadedAt = time.Now()
+	k.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), unknownKeyReloadTimeout)
+	defer cancel()
+
+	if err := k.reload(ctx); err != nil {
+		k.log.Error("failed to reload keys", slog.String("kid", kid), e.SlogErr(err))
+		return nil, false
+	}
+
+	return k.Lookup(kid)
+}
+
+func (k *Keyring) Keys() []*Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	keys := make([]*Key, 0, len(k.keys))
+	for _, key := range k.keys {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].id < keys[j].id
+	})
+
+	return keys
+}
+
+func (k *Keyring) store(ctx context.Context, key *Key, activatesAt time.Time) error {
+	private, err := key.marshal()
+	if err != nil {
+		return err
+	}
+
+	err = k.storage.CreateSigningKey(ctx, StoredKey{
+		ID:          key.ID(),
+		Algorithm:   key.Algorithm(),
+		PrivateKey:  private,
+		CreatedAt:   time.Now(),
+		ActivatesAt: activatesAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	return k.reload(ctx)
+}
+
+func (k *Keyring) reload(ctx context.Context) error {
+	stored, err := k.storage.ListSigningKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	var signers []signer
+	var latest time.Time
+	keys := make(map[string]*Key, len(stored))
+
+	for _, s := range stored {
+		if s.RetiredAt != nil && time.Since(*s.RetiredAt) > k.gracePeriod {
+			continue
+		}
+
+		key, err := unmarshalKey(s.Algorithm, s.PrivateKey)
+		if err != nil {
+			k.log.Error("skipping unreadable signing key", slog.String("kid", s.ID), e.SlogErr(err))
+			continue
+		}
+
+		keys[key.ID()] = key
+
+		// A retired key still signs until the key replacing it activates.
+		if s.RetiredAt == nil || s.RetiredAt.After(time.Now()) {
+			signers = append(signers, signer{key: key, from: s.ActivatesAt})
+			if s.ActivatesAt.After(latest) {
+				latest = s.ActivatesAt
+			}
+		}
+	}
+
+	sort.SliceStable(signers, func(i, j int) bool {
+		return signers[i].from.Before(signers[j].from)
+	})
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.keys = keys
+	k.signers = signers
+	k.latest = latest
+
+	return nil
+}
+
+func GenerateKey(algorithm string) (*Key, error) {
+	method := jwt.GetSigningMethod(algorithm)
+	if method == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+
+	key := &Key{method: method}
+
+	switch m := method.(type) {
+	case *jwt.SigningMethodHMAC:
+		secret := make([]byte, m.Hash.Size())
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+
+		key.privateKey = secret
+		key.publicKey = secret
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		private, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, err
+		}
+
+		key.privateKey = private
+		key.publicKey = &private.PublicKey
+	case *jwt.SigningMethodECDSA:
+		var curve elliptic.Curve
+		switch m.CurveBits {
+		case 256:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		default:
+			curve = elliptic.P521()
+		}
+
+		private, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+
+		key.privateKey = private
+		key.publicKey = &private.PublicKey
+	case *jwt.SigningMethodEd25519:
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+
+		key.privateKey = private
+		key.publicKey = public
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+
+	key.id = key.thumbprint()
+	return key, nil
+}
+
+// marshal encodes the private part of the key: asymmetric keys as PKCS #8
+// PEM, HMAC secrets as is.
+func (k *Key) marshal() ([]byte, error) {
+	if secret, ok := k.privateKey.([]byte); ok {
+		return secret, nil
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(k.privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+func unmarshalKey(algorithm string, private []byte) (*Key, error) {
+	if _, ok := jwt.GetSigningMethod(algorithm).(*jwt.SigningMethodHMAC); ok {
+		return NewHMACKey(algorithm, private)
+	}
+
+	return LoadKey(algorithm, private, nil)
+}
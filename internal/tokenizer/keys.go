@@ -0,0 +1,205 @@
+package tokenizer
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrKeyMismatch          = errors.New("key does not match signing algorithm")
+	ErrNoSigningKey         = errors.New("no signing key configured")
+)
+
+// Key is a signing method together with the keys used to sign and verify
+// tokens. A Key without a private part can only verify tokens.
+type Key struct {
+	id         string
+	method     jwt.SigningMethod
+	privateKey interface{}
+	publicKey  interface{}
+}
+
+func NewHMACKey(algorithm string, secret []byte) (*Key, error) {
+	method, ok := jwt.GetSigningMethod(algorithm).(*jwt.SigningMethodHMAC)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+
+	if len(secret) == 0 {
+		return nil, errors.New("empty hmac secret")
+	}
+
+	key := &Key{
+		method:     method,
+		privateKey: secret,
+		publicKey:  secret,
+	}
+	key.id = key.thumbprint()
+
+	return key, nil
+}
+
+// LoadKey parses PEM encoded keys for an asymmetric algorithm. Either of
+// privatePEM and publicPEM may be empty: the public key is derived from the
+// private one, and a lone public key yields a verification-only Key.
+func LoadKey(algorithm string, privatePEM []byte, publicPEM []byte) (*Key, error) {
+	method := jwt.GetSigningMethod(algorithm)
+	if method == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+
+	if len(privatePEM) == 0 && len(publicPEM) == 0 {
+		return nil, errors.New("neither private nor public key provided")
+	}
+
+	key := &Key{method: method}
+
+	var err error
+	switch m := method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		err = key.parseRSA(privatePEM, publicPEM)
+	case *jwt.SigningMethodECDSA:
+		err = key.parseECDSA(m, privatePEM, publicPEM)
+	case *jwt.SigningMethodEd25519:
+		err = key.parseEd25519(privatePEM, publicPEM)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	key.id = key.thumbprint()
+	return key, nil
+}
+
+func LoadKeyFiles(algorithm string, privatePath string, publicPath string) (*Key, error) {
+	var privatePEM, publicPEM []byte
+	var err error
+
+	if privatePath != "" {
+		privatePEM, err = os.ReadFile(privatePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key: %w", err)
+		}
+	}
+
+	if publicPath != "" {
+		publicPEM, err = os.ReadFile(publicPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read public key: %w", err)
+		}
+	}
+
+	return LoadKey(algorithm, privatePEM, publicPEM)
+}
+
+func (k *Key) ID() string {
+	return k.id
+}
+
+func (k *Key) Algorithm() string {
+	return k.method.Alg()
+}
+
+func (k *Key) CanSign() bool {
+	return k.privateKey != nil
+}
+
+func (k *Key) parseRSA(privatePEM []byte, publicPEM []byte) error {
+	if len(privatePEM) != 0 {
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+		if err != nil {
+			return err
+		}
+
+		k.privateKey = private
+		k.publicKey = &private.PublicKey
+	}
+
+	if len(publicPEM) != 0 {
+		public, err := jwt.ParseRSAPublicKeyFromPEM(publicPEM)
+		if err != nil {
+			return err
+		}
+
+		if private, ok := k.privateKey.(*rsa.PrivateKey); ok && !private.PublicKey.Equal(public) {
+			return ErrKeyMismatch
+		}
+
+		k.publicKey = public
+	}
+
+	return nil
+}
+
+func (k *Key) parseECDSA(method *jwt.SigningMethodECDSA, privatePEM []byte, publicPEM []byte) error {
+	if len(privatePEM) != 0 {
+		private, err := jwt.ParseECPrivateKeyFromPEM(privatePEM)
+		if err != nil {
+			return err
+		}
+
+		k.privateKey = private
+		k.publicKey = &private.PublicKey
+	}
+
+	if len(publicPEM) != 0 {
+		public, err := jwt.ParseECPublicKeyFromPEM(publicPEM)
+		if err != nil {
+			return err
+		}
+
+		if private, ok := k.privateKey.(*ecdsa.PrivateKey); ok && !private.PublicKey.Equal(public) {
+			return ErrKeyMismatch
+		}
+
+		k.publicKey = public
+	}
+
+	if k.publicKey.(*ecdsa.PublicKey).Curve.Params().BitSize != method.CurveBits {
+		return ErrKeyMismatch
+	}
+
+	return nil
+}
+
+func (k *Key) parseEd25519(privatePEM []byte, publicPEM []byte) error {
+	if len(privatePEM) != 0 {
+		private, err := jwt.ParseEdPrivateKeyFromPEM(privatePEM)
+		if err != nil {
+			return err
+		}
+
+		signer, ok := private.(ed25519.PrivateKey)
+		if !ok {
+			return ErrKeyMismatch
+		}
+
+		k.privateKey = signer
+		k.publicKey = signer.Public()
+	}
+
+	if len(publicPEM) != 0 {
+		public, err := jwt.ParseEdPublicKeyFromPEM(publicPEM)
+		if err != nil {
+			return err
+		}
+
+		if private, ok := k.privateKey.(ed25519.PrivateKey); ok && !public.(ed25519.PublicKey).Equal(private.Public()) {
+			return ErrKeyMismatch
+		}
+
+		k.publicKey = public
+	}
+
+	return nil
+}
@@ -1,63 +1,203 @@
 package tokenizer
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"strconv"
 	"time"
 
+	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
 	"github.com/AleksandrVishniakov/jwt-auth/internal/handlers"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/roles"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/usecases"
 	"github.com/golang-jwt/jwt"
 )
 
 var (
-	ErrInvalidToken = errors.New("invalid token")
+	ErrInvalidToken      = errors.New("invalid token")
+	ErrLegacyTokenFormat = errors.New("legacy permissions mask is no longer accepted")
 )
 
 type Tokenizer struct {
-	signature []byte
-	tokenTTL  time.Duration
+	keys            *Keyring
+	tokenTTL        time.Duration
+	issuer          string
+	audience        string
+	clientAudiences map[string][]string
+	leeway          time.Duration
+	legacyMaskUntil time.Time
 }
 
-func New(signature []byte, tokenTTL time.Duration) *Tokenizer {
+// New creates a Tokenizer issuing tokens as issuer. audience identifies this
+// service: it is required in every parsed token and is the audience of
+// tokens issued without a client. clientAudiences lists the audiences of
+// tokens issued for each known client.
+//
+// Until legacyMaskUntil tokens keep carrying the int64 permissionMask claim
+// next to perms, and tokens with only the former are accepted. A zero
+// legacyMaskUntil keeps the legacy format with no end date.
+func New(
+	keys *Keyring,
+	tokenTTL time.Duration,
+	issuer string,
+	audience string,
+	clientAudiences map[string][]string,
+	leeway time.Duration,
+	legacyMaskUntil time.Time,
+) *Tokenizer {
 	return &Tokenizer{
-		signature: signature,
-		tokenTTL:  tokenTTL,
+		keys:            keys,
+		tokenTTL:        tokenTTL,
+		issuer:          issuer,
+		audience:        audience,
+		clientAudiences: clientAudiences,
+		leeway:          leeway,
+		legacyMaskUntil: legacyMaskUntil,
 	}
 }
 
-type tokenClaims struct {
-	jwt.StandardClaims
-	handlers.TokenData
+func (t *Tokenizer) ValidateClient(clientID string) error {
+	if clientID == "" {
+		return nil
+	}
+
+	if _, ok := t.clientAudiences[clientID]; !ok {
+		return e.ErrUnknownClient
+	}
+
+	return nil
 }
 
-func (t *Tokenizer) Token(userID int32, role string, permissionMask int64) (string, error) {
-	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &tokenClaims{
-		StandardClaims: jwt.StandardClaims{
-			IssuedAt:  time.Now().Unix(),
-			ExpiresAt: time.Now().Add(t.tokenTTL).Unix(),
-		},
-		TokenData: handlers.TokenData{
-			UserID:         userID,
-			Role:           role,
-			PermissionMask: permissionMask,
+func (t *Tokenizer) Token(subject *usecases.TokenSubject) (string, error) {
+	if err := t.ValidateClient(subject.ClientID); err != nil {
+		return "", err
+	}
+
+	aud := []string{t.audience}
+	if subject.ClientID != "" {
+		aud = t.clientAudiences[subject.ClientID]
+	}
+
+	key := t.keys.Active()
+	if key == nil || !key.CanSign() {
+		return "", ErrNoSigningKey
+	}
+
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	sub := strconv.Itoa(int(subject.UserID))
+	if subject.IsService() {
+		sub = subject.ClientID
+	}
+
+	now := time.Now()
+	data := handlers.TokenData{
+		UserID:         subject.UserID,
+		Roles:          subject.Roles,
+		PermissionMask: subject.PermissionMask,
+		TokenVersion:   subject.TokenVersion,
+		ClientID:       subject.ClientID,
+		OrgID:          subject.OrgID,
+	}
+
+	// masks with bits above 62 have no legacy form, consumers still reading
+	// permissionMask see none of their permissions
+	if t.acceptsLegacyMask(now) {
+		data.LegacyPermissionMask, _ = subject.PermissionMask.Int64()
+	}
+
+	// a token does not outlive the temporary roles it carries
+	expiresAt := now.Add(t.tokenTTL)
+	if !subject.NotAfter.IsZero() && subject.NotAfter.Before(expiresAt) {
+		expiresAt = subject.NotAfter
+	}
+
+	token := jwt.NewWithClaims(key.method, &tokenClaims{
+		registeredClaims: registeredClaims{
+			Issuer:    t.issuer,
+			Subject:   sub,
+			Audience:  aud,
+			ExpiresAt: expiresAt.Unix(),
+			NotBefore: now.Unix(),
+			IssuedAt:  now.Unix(),
+			ID:        jti,
 		},
-	}).SignedString(t.signature)
+		TokenData: data,
+	})
+	token.Header["kid"] = key.ID()
 
+	signed, err := token.SignedString(key.privateKey)
 	if err != nil {
 		return "", err
 	}
 
-	return token, nil
+	return signed, nil
+}
+
+// IDToken issues an OpenID Connect ID token. Its audience is the client the
+// user authenticated to rather than the resource servers.
+func (t *Tokenizer) IDToken(subject *usecases.IDTokenSubject) (string, error) {
+	if err := t.ValidateClient(subject.ClientID); err != nil {
+		return "", err
+	}
+
+	key := t.keys.Active()
+	if key == nil || !key.CanSign() {
+		return "", ErrNoSigningKey
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(key.method, &idTokenClaims{
+		registeredClaims: registeredClaims{
+			Issuer:    t.issuer,
+			Subject:   strconv.Itoa(int(subject.UserID)),
+			Audience:  audience{subject.ClientID},
+			ExpiresAt: now.Add(t.tokenTTL).Unix(),
+			IssuedAt:  now.Unix(),
+		},
+		Nonce:             subject.Nonce,
+		AuthTime:          subject.AuthTime.Unix(),
+		PreferredUsername: subject.Login,
+	})
+	token.Header["kid"] = key.ID()
+
+	return token.SignedString(key.privateKey)
 }
 
 func (t *Tokenizer) Parse(token string) (data handlers.TokenData, err error) {
+	return t.parse(token, t.validate)
+}
+
+// ParseAnyAudience parses tokens issued to any audience, it is used to
+// introspect tokens on behalf of the resource servers they were issued to.
+func (t *Tokenizer) ParseAnyAudience(token string) (data handlers.TokenData, err error) {
+	return t.parse(token, t.validateAnyAudience)
+}
+
+func (t *Tokenizer) parse(
+	token string,
+	validate func(claims *registeredClaims, now time.Time) error,
+) (data handlers.TokenData, err error) {
 	jwtToken, err := jwt.ParseWithClaims(
 		token, &tokenClaims{},
 		func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			key := t.keys.Active()
+			if kid, ok := token.Header["kid"].(string); ok {
+				key, ok = t.keys.Resolve(kid)
+				if !ok {
+					return nil, ErrUnknownKey
+				}
+			}
+
+			if key == nil || token.Method.Alg() != key.Algorithm() {
 				return nil, ErrInvalidToken
 			}
 
-			return t.signature, nil
+			return key.publicKey, nil
 		})
 
 	if err != nil {
@@ -69,5 +209,61 @@ func (t *Tokenizer) Parse(token string) (data handlers.TokenData, err error) {
 		return handlers.TokenData{}, ErrInvalidToken
 	}
 
-	return claims.TokenData, nil
+	now := time.Now()
+	if err := validate(&claims.registeredClaims, now); err != nil {
+		return handlers.TokenData{}, err
+	}
+
+	data = claims.TokenData
+	if len(data.PermissionMask) == 0 && data.LegacyPermissionMask != 0 {
+		if !t.acceptsLegacyMask(now) {
+			return handlers.TokenData{}, ErrLegacyTokenFormat
+		}
+
+		data.PermissionMask = roles.MaskFromInt64(data.LegacyPermissionMask)
+	}
+
+	data.TokenID = claims.ID
+	data.Audience = claims.registeredClaims.Audience
+	data.ExpiresAt = time.Unix(claims.registeredClaims.ExpiresAt, 0)
+
+	return data, nil
+}
+
+func (t *Tokenizer) acceptsLegacyMask(now time.Time) bool {
+	return t.legacyMaskUntil.IsZero() || now.Before(t.legacyMaskUntil)
+}
+
+func (t *Tokenizer) JWKS() handlers.JWKSet {
+	set := handlers.JWKSet{Keys: []handlers.JWK{}}
+
+	for _, key := range t.keys.Keys() {
+		if jwk, ok := key.jwk(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+
+	return set
+}
+
+func (t *Tokenizer) Issuer() string {
+	return t.issuer
+}
+
+func (t *Tokenizer) SigningAlgorithms() []string {
+	key := t.keys.Active()
+	if key == nil {
+		return []string{}
+	}
+
+	return []string{key.Algorithm()}
+}
+
+func newTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
 }
@@ -0,0 +1,134 @@
+package tokenizer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/roles"
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	testIssuer   = "https://auth.example.com"
+	testAudience = "jwt-auth"
+)
+
+// memoryKeys keeps signing keys for the lifetime of a test.
+type memoryKeys struct {
+	keys []StoredKey
+}
+
+func (m *memoryKeys) ListSigningKeys(ctx context.Context) ([]StoredKey, error) {
+	return m.keys, nil
+}
+
+func (m *memoryKeys) CreateSigningKey(ctx context.Context, key StoredKey) error {
+	for i := range m.keys {
+		if m.keys[i].RetiredAt == nil {
+			m.keys[i].RetiredAt = &key.ActivatesAt
+		}
+	}
+
+	m.keys = append(m.keys, key)
+	return nil
+}
+
+func (m *memoryKeys) DeleteSigningKeysRetiredBefore(ctx context.Context, before time.Time) error {
+	return nil
+}
+
+func newTestTokenizer(t *testing.T, legacyMaskUntil time.Time) (*Tokenizer, *Key) {
+	t.Helper()
+
+	key, err := NewHMACKey("HS256", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("NewHMACKey: %v", err)
+	}
+
+	keys := NewKeyring(slog.New(slog.NewTextHandler(io.Discard, nil)), &memoryKeys{}, "HS256", 0, time.Hour, 0)
+	if err := keys.Init(context.Background(), key); err != nil {
+		t.Fatalf("Keyring.Init: %v", err)
+	}
+
+	return New(keys, time.Minute, testIssuer, testAudience, nil, 0, legacyMaskUntil), key
+}
+
+func signTestToken(t *testing.T, key *Key, claims jwt.MapClaims) string {
+	t.Helper()
+
+	claims["iss"] = testIssuer
+	claims["aud"] = testAudience
+	claims["exp"] = time.Now().Add(time.Minute).Unix()
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.ID()
+
+	signed, err := token.SignedString(key.privateKey)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+
+	return signed
+}
+
+func TestParseLegacyPermissionMask(t *testing.T) {
+	legacy := int64(1<<roles.CanUpdateUserRole | 1<<roles.CanBanUsers)
+	perms := roles.NewMask(roles.CanManageRoles)
+
+	tests := []struct {
+		name            string
+		claims          jwt.MapClaims
+		legacyMaskUntil time.Time
+		want            roles.Mask
+		wantErr         error
+	}{
+		{
+			name:   "legacy mask only",
+			claims: jwt.MapClaims{"permissionMask": legacy},
+			want:   roles.MaskFromInt64(legacy),
+		},
+		{
+			name:            "legacy mask before the deadline",
+			claims:          jwt.MapClaims{"permissionMask": legacy},
+			legacyMaskUntil: time.Now().Add(time.Hour),
+			want:            roles.MaskFromInt64(legacy),
+		},
+		{
+			name:            "legacy mask after the deadline",
+			claims:          jwt.MapClaims{"permissionMask": legacy},
+			legacyMaskUntil: time.Now().Add(-time.Hour),
+			wantErr:         ErrLegacyTokenFormat,
+		},
+		{
+			name:   "perms win over the legacy mask",
+			claims: jwt.MapClaims{"perms": perms.String(), "permissionMask": legacy},
+			want:   perms,
+		},
+		{
+			name:            "perms after the deadline",
+			claims:          jwt.MapClaims{"perms": perms.String()},
+			legacyMaskUntil: time.Now().Add(-time.Hour),
+			want:            perms,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenizer, key := newTestTokenizer(t, tt.legacyMaskUntil)
+
+			data, err := tokenizer.Parse(signTestToken(t, key, tt.claims))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if !bytes.Equal(data.PermissionMask, tt.want) {
+				t.Errorf("Parse() mask = %v, want %v", data.PermissionMask, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package tokenversion
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxEntries bounds the cache before expired entries are swept.
+const maxEntries = 10000
+
+type Storage interface {
+	GetUserTokenState(
+		ctx context.Context,
+		userID int32,
+		orgID int32,
+	) (version int32, banned bool, err error)
+}
+
+type entry struct {
+	version   int32
+	banned    bool
+	expiresAt time.Time
+}
+
+// Cache remembers the current token version of users and whether they are
+// banned in an organization for a short time, so authenticating a request does not always hit the database.
+// Changes made by this instance are seen immediately through Invalidate,
+// changes made by other instances once the entry expires.
+type Cache struct {
+	storage Storage
+	ttl     time.Duration
+
+	mu      sync.Mutex
+	size    int
+	entries map[int32]map[int32]entry
+}
+
+func NewCache(storage Storage, ttl time.Duration) *Cache {
+	return &Cache{
+		storage: storage,
+		ttl:     ttl,
+		entries: map[int32]map[int32]entry{},
+	}
+}
+
+func (c *Cache) TokenState(
+	ctx context.Context,
+	userID int32,
+	orgID int32,
+) (version int32, banned bool, err error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	cached, ok := c.entries[userID][orgID]
+	c.mu.Unlock()
+
+	if ok && now.Before(cached.expiresAt) {
+		return cached.version, cached.banned, nil
+	}
+
+	version, banned, err = c.storage.GetUserTokenState(ctx, userID, orgID)
+	if err != nil {
+		return 0, false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.size >= maxEntries {
+		c.sweep(now)
+	}
+
+	orgs, ok := c.entries[userID]
+	if !ok {
+		orgs = map[int32]entry{}
+		c.entries[userID] = orgs
+	}
+	if _, ok := orgs[orgID]; !ok {
+		c.size++
+	}
+
+	orgs[orgID] = entry{
+		version:   version,
+		banned:    banned,
+		expiresAt: now.Add(c.ttl),
+	}
+
+	return version, banned, nil
+}
+
+// Invalidate forgets a user in every organization.
+func (c *Cache) Invalidate(userID int32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.size -= len(c.entries[userID])
+	delete(c.entries, userID)
+}
+
+// InvalidateAll forgets every user, for changes affecting many of them at
+// once such as editing a role.
+func (c *Cache) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.entries)
+	c.size = 0
+}
+
+func (c *Cache) sweep(now time.Time) {
+	for userID, orgs := range c.entries {
+		for orgID, e := range orgs {
+			if now.After(e.expiresAt) {
+				delete(orgs, orgID)
+				c.size--
+			}
+		}
+
+		if len(orgs) == 0 {
+			delete(c.entries, userID)
+		}
+	}
+}
@@ -2,23 +2,30 @@ package usecases
 
 import (
 	"errors"
-	"slices"
+	"net/url"
+	"regexp"
+	"time"
 	"unicode/utf8"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/roles"
 )
 
 type LoginRequest struct {
 	Login    string
 	Password string
+	ClientID string
 }
 
 type LoginResponse struct {
-	ID    int32
-	Token string
+	ID           int32
+	Token        string
+	RefreshToken string
 }
 
 func NewLoginRequest(
 	login string,
 	password string,
+	clientID string,
 ) (*LoginRequest, error) {
 	if len(login) < 3 || len(login) > 64 {
 		return nil, errors.New("invalid login length")
@@ -32,25 +39,33 @@ func NewLoginRequest(
 		return nil, errors.New("invalid password length")
 	}
 
+	if len(clientID) > 64 {
+		return nil, errors.New("invalid client id length")
+	}
+
 	return &LoginRequest{
 		Login:    login,
 		Password: password,
+		ClientID: clientID,
 	}, nil
 }
 
 type RegisterRequest struct {
 	Login    string
 	Password string
+	ClientID string
 }
 
 type RegisterResponse struct {
-	ID    int32
-	Token string
+	ID           int32
+	Token        string
+	RefreshToken string
 }
 
 func NewRegisterRequest(
 	login string,
 	password string,
+	clientID string,
 ) (*RegisterRequest, error) {
 	if len(login) < 3 || len(login) > 64 {
 		return nil, errors.New("invalid login length")
@@ -64,64 +79,785 @@ func NewRegisterRequest(
 		return nil, errors.New("invalid password length")
 	}
 
+	if len(clientID) > 64 {
+		return nil, errors.New("invalid client id length")
+	}
+
 	return &RegisterRequest{
 		Login:    login,
 		Password: password,
+		ClientID: clientID,
 	}, nil
 }
 
-type UpdateUserRoleRequest struct {
-	UserID          int32
-	Role            string
-	PermissionsMask int64
+// RefreshRequest redeems a refresh token. ClientSecret authenticates the
+// client the token was issued to when that client is confidential.
+type RefreshRequest struct {
+	RefreshToken string
+	ClientID     string
+	ClientSecret string
+}
+
+type RefreshResponse struct {
+	ID           int32
+	Token        string
+	RefreshToken string
+}
+
+func NewRefreshRequest(
+	refreshToken string,
+	clientID string,
+	clientSecret string,
+) (*RefreshRequest, error) {
+	if refreshToken == "" {
+		return nil, errors.New("empty refresh token")
+	}
+
+	if len(refreshToken) > 128 {
+		return nil, errors.New("invalid refresh token length")
+	}
+
+	if len(clientID) > 64 {
+		return nil, errors.New("invalid client id length")
+	}
+
+	if len(clientSecret) > 72 {
+		return nil, errors.New("invalid client secret length")
+	}
+
+	return &RefreshRequest{
+		RefreshToken: refreshToken,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+	}, nil
+}
+
+type LogoutRequest struct {
+	TokenID      string
+	ExpiresAt    time.Time
+	RefreshToken string
+}
+
+func NewLogoutRequest(
+	tokenID string,
+	expiresAt time.Time,
+	refreshToken string,
+) (*LogoutRequest, error) {
+	if tokenID == "" {
+		return nil, errors.New("empty token id")
+	}
+
+	if len(refreshToken) > 128 {
+		return nil, errors.New("invalid refresh token length")
+	}
+
+	return &LogoutRequest{
+		TokenID:      tokenID,
+		ExpiresAt:    expiresAt,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// UserRoleRequest assigns a role to or unassigns it from a user in the
+// organization OrgID. A role assigned with ExpiresAt is taken back at that
+// time.
+type UserRoleRequest struct {
+	UserID         int32
+	OrgID          int32
+	PermissionMask roles.Mask
+	ProfileID      int32
+	Role           string
+	ExpiresAt      time.Time
 }
 
-func NewUpdateUserRoleRequest(
+func NewUserRoleRequest(
 	userID int32,
+	orgID int32,
+	permissionMask roles.Mask,
+	profileID int32,
 	role string,
-	permissionsMask int64,
-) (*UpdateUserRoleRequest, error) {
-	var existingRoles = []string{"student", "admin"}
-
-	if userID < 1 {
+	expiresAt time.Time,
+) (*UserRoleRequest, error) {
+	if profileID < 1 {
 		return nil, errors.New("invalid user id")
 	}
 
-	if permissionsMask < 0 {
-		return nil, errors.New("invalid permissions mask")
+	if !roleAlias.MatchString(role) {
+		return nil, errors.New("invalid role alias")
 	}
 
-	if !slices.Contains(existingRoles, role) {
-		return nil, errors.New("unknown role")
+	if !expiresAt.IsZero() && !expiresAt.After(time.Now()) {
+		return nil, errors.New("role expiration must be in the future")
 	}
 
-	return &UpdateUserRoleRequest{
-		UserID:          userID,
-		Role:            role,
-		PermissionsMask: permissionsMask,
+	return &UserRoleRequest{
+		UserID:         userID,
+		OrgID:          orgID,
+		PermissionMask: permissionMask,
+		ProfileID:      profileID,
+		Role:           role,
+		ExpiresAt:      expiresAt,
+	}, nil
+}
+
+type ListRoleGrantsRequest struct {
+	OrgID          int32
+	PermissionMask roles.Mask
+}
+
+type RoleGrantResponse struct {
+	UserID    int32
+	Login     string
+	Role      string
+	GrantedAt time.Time
+	ExpiresAt time.Time
+}
+
+type ListRoleGrantsResponse struct {
+	Grants []*RoleGrantResponse
+}
+
+func NewListRoleGrantsRequest(
+	orgID int32,
+	permissionMask roles.Mask,
+) (*ListRoleGrantsRequest, error) {
+	return &ListRoleGrantsRequest{
+		OrgID:          orgID,
+		PermissionMask: permissionMask,
+	}, nil
+}
+
+// SetUserBannedRequest bans a member of the active organization of the
+// caller.
+type SetUserBannedRequest struct {
+	UserID         int32
+	OrgID          int32
+	PermissionMask roles.Mask
+	ProfileID      int32
+	Banned         bool
+}
+
+func NewSetUserBannedRequest(
+	userID int32,
+	orgID int32,
+	permissionMask roles.Mask,
+	profileID int32,
+	banned bool,
+) (*SetUserBannedRequest, error) {
+	if profileID < 1 {
+		return nil, errors.New("invalid user id")
+	}
+
+	return &SetUserBannedRequest{
+		UserID:         userID,
+		OrgID:          orgID,
+		PermissionMask: permissionMask,
+		ProfileID:      profileID,
+		Banned:         banned,
 	}, nil
 }
 
 type GetUserByIDRequest struct {
 	UserID         int32
-	PermissionMask int64
+	OrgID          int32
+	Roles          []string
+	PermissionMask roles.Mask
 	ProfileID      int32
 }
 
 type GetUserByIDResponse struct {
 	ID    int32
 	Login string
-	Role  string
+	Roles []string
 }
 
 func NewGetUserByIDRequest(
 	userID int32,
-	permissionMask int64,
+	orgID int32,
+	userRoles []string,
+	permissionMask roles.Mask,
 	profileID int32,
 ) (*GetUserByIDRequest, error) {
 	return &GetUserByIDRequest{
 		UserID:         userID,
+		OrgID:          orgID,
+		Roles:          userRoles,
 		PermissionMask: permissionMask,
 		ProfileID:      profileID,
 	}, nil
 }
+
+type RotateSigningKeyRequest struct {
+	PermissionMask roles.Mask
+}
+
+type RotateSigningKeyResponse struct {
+	KeyID string
+}
+
+func NewRotateSigningKeyRequest(
+	permissionMask roles.Mask,
+) (*RotateSigningKeyRequest, error) {
+	return &RotateSigningKeyRequest{
+		PermissionMask: permissionMask,
+	}, nil
+}
+
+// pkceValue matches RFC 7636 code verifiers and S256 code challenges.
+var pkceValue = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+type AuthorizationRequest struct {
+	ClientID      string
+	RedirectURI   string
+	CodeChallenge string
+	Scope         string
+	State         string
+	Nonce         string
+}
+
+func NewAuthorizationRequest(
+	responseType string,
+	clientID string,
+	redirectURI string,
+	codeChallenge string,
+	codeChallengeMethod string,
+	scope string,
+	state string,
+	nonce string,
+) (*AuthorizationRequest, error) {
+	if responseType != "code" {
+		return nil, errors.New("unsupported response type")
+	}
+
+	if clientID == "" || len(clientID) > 64 {
+		return nil, errors.New("invalid client id")
+	}
+
+	if _, err := url.ParseRequestURI(redirectURI); err != nil {
+		return nil, errors.New("invalid redirect uri")
+	}
+
+	if codeChallengeMethod != "S256" {
+		return nil, errors.New("code challenge method must be S256")
+	}
+
+	if !pkceValue.MatchString(codeChallenge) {
+		return nil, errors.New("invalid code challenge")
+	}
+
+	if len(scope) > 512 || len(state) > 512 || len(nonce) > 512 {
+		return nil, errors.New("invalid scope, state or nonce length")
+	}
+
+	return &AuthorizationRequest{
+		ClientID:      clientID,
+		RedirectURI:   redirectURI,
+		CodeChallenge: codeChallenge,
+		Scope:         scope,
+		State:         state,
+		Nonce:         nonce,
+	}, nil
+}
+
+type AuthorizeRequest struct {
+	*AuthorizationRequest
+	Login    string
+	Password string
+}
+
+type AuthorizeResponse struct {
+	Code string
+}
+
+func NewAuthorizeRequest(
+	authorization *AuthorizationRequest,
+	login string,
+	password string,
+) (*AuthorizeRequest, error) {
+	credentials, err := NewLoginRequest(login, password, authorization.ClientID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthorizeRequest{
+		AuthorizationRequest: authorization,
+		Login:                credentials.Login,
+		Password:             credentials.Password,
+	}, nil
+}
+
+type ExchangeCodeRequest struct {
+	Code         string
+	ClientID     string
+	ClientSecret string
+	RedirectURI  string
+	CodeVerifier string
+}
+
+func NewExchangeCodeRequest(
+	code string,
+	clientID string,
+	clientSecret string,
+	redirectURI string,
+	codeVerifier string,
+) (*ExchangeCodeRequest, error) {
+	if code == "" || len(code) > 128 {
+		return nil, errors.New("invalid code")
+	}
+
+	if clientID == "" || len(clientID) > 64 {
+		return nil, errors.New("invalid client id")
+	}
+
+	if len(clientSecret) > 72 {
+		return nil, errors.New("invalid client secret")
+	}
+
+	if redirectURI == "" {
+		return nil, errors.New("empty redirect uri")
+	}
+
+	if !pkceValue.MatchString(codeVerifier) {
+		return nil, errors.New("invalid code verifier")
+	}
+
+	return &ExchangeCodeRequest{
+		Code:         code,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURI:  redirectURI,
+		CodeVerifier: codeVerifier,
+	}, nil
+}
+
+type TokenResponse struct {
+	UserID       int32
+	AccessToken  string
+	RefreshToken string
+	IDToken      string
+	ExpiresIn    int
+	Scope        string
+}
+
+type ClientCredentialsRequest struct {
+	ClientID     string
+	ClientSecret string
+	Scope        string
+}
+
+func NewClientCredentialsRequest(
+	clientID string,
+	clientSecret string,
+	scope string,
+) (*ClientCredentialsRequest, error) {
+	if clientID == "" || len(clientID) > 64 {
+		return nil, errors.New("invalid client id")
+	}
+
+	if clientSecret == "" || len(clientSecret) > 72 {
+		return nil, errors.New("invalid client secret")
+	}
+
+	if len(scope) > 512 {
+		return nil, errors.New("invalid scope length")
+	}
+
+	return &ClientCredentialsRequest{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Scope:        scope,
+	}, nil
+}
+
+// roleAlias matches aliases of roles managed through the API.
+var roleAlias = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+type ListRolesRequest struct {
+	PermissionMask roles.Mask
+}
+
+type RoleResponse struct {
+	Alias       string
+	Permissions []string
+	IsDefault   bool
+	IsSuper     bool
+	Overridden  bool
+}
+
+type ListRolesResponse struct {
+	Roles []*RoleResponse
+}
+
+func NewListRolesRequest(
+	permissionMask roles.Mask,
+) (*ListRolesRequest, error) {
+	return &ListRolesRequest{
+		PermissionMask: permissionMask,
+	}, nil
+}
+
+type GetRoleRequest struct {
+	PermissionMask roles.Mask
+	Alias          string
+}
+
+func NewGetRoleRequest(
+	permissionMask roles.Mask,
+	alias string,
+) (*GetRoleRequest, error) {
+	if !roleAlias.MatchString(alias) {
+		return nil, errors.New("invalid role alias")
+	}
+
+	return &GetRoleRequest{
+		PermissionMask: permissionMask,
+		Alias:          alias,
+	}, nil
+}
+
+// SaveRoleRequest creates a role or replaces an existing one. Roles are the
+// roles of the caller.
+type SaveRoleRequest struct {
+	PermissionMask roles.Mask
+	Roles          []string
+	Role           *RoleModel
+}
+
+func NewSaveRoleRequest(
+	permissionMask roles.Mask,
+	userRoles []string,
+	alias string,
+	permissions []string,
+	isDefault bool,
+	isSuper bool,
+) (*SaveRoleRequest, error) {
+	if !roleAlias.MatchString(alias) {
+		return nil, errors.New("invalid role alias")
+	}
+
+	if isDefault && isSuper {
+		return nil, errors.New("role cannot be both default and super")
+	}
+
+	roleMask, err := roles.ParsePermissions(permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SaveRoleRequest{
+		PermissionMask: permissionMask,
+		Roles:          userRoles,
+		Role: &RoleModel{
+			Alias:          alias,
+			PermissionMask: roleMask,
+			IsDefault:      isDefault,
+			IsSuper:        isSuper,
+		},
+	}, nil
+}
+
+type RoleHierarchyRequest struct {
+	PermissionMask roles.Mask
+}
+
+type ResolvedRoleResponse struct {
+	Alias                string
+	Inherits             []string
+	Ancestors            []string
+	Permissions          []string
+	EffectivePermissions []string
+	IsDefault            bool
+	IsSuper              bool
+	Overridden           bool
+}
+
+type RoleHierarchyResponse struct {
+	Roles []*ResolvedRoleResponse
+}
+
+func NewRoleHierarchyRequest(
+	permissionMask roles.Mask,
+) (*RoleHierarchyRequest, error) {
+	return &RoleHierarchyRequest{
+		PermissionMask: permissionMask,
+	}, nil
+}
+
+// DeleteRoleRequest deletes a role, moving its holders to ReassignTo when
+// set. Roles are the roles of the caller.
+type DeleteRoleRequest struct {
+	PermissionMask roles.Mask
+	Roles          []string
+	Alias          string
+	ReassignTo     string
+}
+
+func NewDeleteRoleRequest(
+	permissionMask roles.Mask,
+	userRoles []string,
+	alias string,
+	reassignTo string,
+) (*DeleteRoleRequest, error) {
+	if !roleAlias.MatchString(alias) {
+		return nil, errors.New("invalid role alias")
+	}
+
+	if reassignTo != "" && !roleAlias.MatchString(reassignTo) {
+		return nil, errors.New("invalid reassignment role alias")
+	}
+
+	if reassignTo == alias {
+		return nil, errors.New("cannot reassign to the deleted role")
+	}
+
+	return &DeleteRoleRequest{
+		PermissionMask: permissionMask,
+		Roles:          userRoles,
+		Alias:          alias,
+		ReassignTo:     reassignTo,
+	}, nil
+}
+
+// CheckAccessRequest asks whether a subject may do an action on a resource.
+type CheckAccessRequest struct {
+	SubjectID    int32
+	SubjectRoles []string
+	Permissions  roles.Mask
+	Action       string
+	ResourceType string
+	OwnerID      int32
+}
+
+type CheckAccessResponse struct {
+	Allowed bool
+	Rule    string
+}
+
+func NewCheckAccessRequest(
+	subjectID int32,
+	subjectRoles []string,
+	permissions []string,
+	action string,
+	resourceType string,
+	ownerID int32,
+) (*CheckAccessRequest, error) {
+	if subjectID < 0 || ownerID < 0 {
+		return nil, errors.New("invalid user id")
+	}
+
+	if action == "" {
+		return nil, errors.New("action is required")
+	}
+
+	if resourceType == "" {
+		return nil, errors.New("resource type is required")
+	}
+
+	mask, err := roles.ParsePermissions(permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CheckAccessRequest{
+		SubjectID:    subjectID,
+		SubjectRoles: subjectRoles,
+		Permissions:  mask,
+		Action:       action,
+		ResourceType: resourceType,
+		OwnerID:      ownerID,
+	}, nil
+}
+
+type ListOrganizationsRequest struct {
+	UserID int32
+	OrgID  int32
+}
+
+type OrganizationResponse struct {
+	ID       int32
+	Alias    string
+	Name     string
+	IsActive bool
+}
+
+type ListOrganizationsResponse struct {
+	Organizations []*OrganizationResponse
+}
+
+func NewListOrganizationsRequest(
+	userID int32,
+	orgID int32,
+) (*ListOrganizationsRequest, error) {
+	return &ListOrganizationsRequest{
+		UserID: userID,
+		OrgID:  orgID,
+	}, nil
+}
+
+// SwitchOrganizationRequest re-issues the tokens of a user for another
+// organization. The token presented is revoked.
+type SwitchOrganizationRequest struct {
+	UserID    int32
+	OrgID     int32
+	ClientID  string
+	TokenID   string
+	ExpiresAt time.Time
+}
+
+type SwitchOrganizationResponse struct {
+	ID           int32
+	Token        string
+	RefreshToken string
+}
+
+func NewSwitchOrganizationRequest(
+	userID int32,
+	orgID int32,
+	clientID string,
+	tokenID string,
+	expiresAt time.Time,
+) (*SwitchOrganizationRequest, error) {
+	if orgID < 1 {
+		return nil, errors.New("invalid organization id")
+	}
+
+	return &SwitchOrganizationRequest{
+		UserID:    userID,
+		OrgID:     orgID,
+		ClientID:  clientID,
+		TokenID:   tokenID,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// ListUsersRequest lists a page of the users of the active organization.
+// Created times are a half-open range, zero bounds are open.
+type ListUsersRequest struct {
+	OrgID          int32
+	PermissionMask roles.Mask
+	Role           string
+	LoginPrefix    string
+	CreatedFrom    time.Time
+	CreatedTo      time.Time
+	Sort           UserSort
+	After          *UserCursor
+	Limit          int
+}
+
+type UserListItemResponse struct {
+	ID        int32
+	Login     string
+	CreatedAt time.Time
+	IsBanned  bool
+}
+
+type ListUsersResponse struct {
+	Users []*UserListItemResponse
+	// NextCursor resumes the listing after this page, empty on the last one.
+	NextCursor string
+}
+
+func NewListUsersRequest(
+	orgID int32,
+	permissionMask roles.Mask,
+	role string,
+	loginPrefix string,
+	createdFrom time.Time,
+	createdTo time.Time,
+	sort string,
+	cursor string,
+	limit int,
+) (*ListUsersRequest, error) {
+	if role != "" && !roleAlias.MatchString(role) {
+		return nil, errors.New("invalid role alias")
+	}
+
+	if len(loginPrefix) > 64 || !utf8.ValidString(loginPrefix) {
+		return nil, errors.New("invalid login prefix")
+	}
+
+	if !createdFrom.IsZero() && !createdTo.IsZero() && !createdFrom.Before(createdTo) {
+		return nil, errors.New("created range is empty")
+	}
+
+	userSort := UserSort(sort)
+	switch userSort {
+	case "":
+		userSort = SortByCreatedAt
+	case SortByLogin, SortByLoginDesc, SortByCreatedAt, SortByCreatedAtDesc:
+	default:
+		return nil, errors.New("invalid sort")
+	}
+
+	switch {
+	case limit == 0:
+		limit = defaultUsersPageSize
+	case limit < 0 || limit > maxUsersPageSize:
+		return nil, errors.New("invalid limit")
+	}
+
+	var after *UserCursor
+	if cursor != "" {
+		var err error
+		after, err = decodeUserCursor(userSort, cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &ListUsersRequest{
+		OrgID:          orgID,
+		PermissionMask: permissionMask,
+		Role:           role,
+		LoginPrefix:    loginPrefix,
+		CreatedFrom:    createdFrom,
+		CreatedTo:      createdTo,
+		Sort:           userSort,
+		After:          after,
+		Limit:          limit,
+	}, nil
+}
+
+// ChangePasswordRequest replaces the password of the caller. The tokens are
+// re-issued for the client and organization of the token presented.
+type ChangePasswordRequest struct {
+	UserID          int32
+	OrgID           int32
+	ClientID        string
+	CurrentPassword string
+	NewPassword     string
+}
+
+type ChangePasswordResponse struct {
+	ID           int32
+	Token        string
+	RefreshToken string
+}
+
+func NewChangePasswordRequest(
+	userID int32,
+	orgID int32,
+	clientID string,
+	currentPassword string,
+	newPassword string,
+) (*ChangePasswordRequest, error) {
+	if currentPassword == "" || len(currentPassword) > 128 {
+		return nil, errors.New("invalid current password length")
+	}
+
+	if len(newPassword) < 3 || len(newPassword) > 128 {
+		return nil, errors.New("invalid password length")
+	}
+
+	if newPassword == currentPassword {
+		return nil, errors.New("new password must differ from the current one")
+	}
+
+	return &ChangePasswordRequest{
+		UserID:          userID,
+		OrgID:           orgID,
+		ClientID:        clientID,
+		CurrentPassword: currentPassword,
+		NewPassword:     newPassword,
+	}, nil
+}
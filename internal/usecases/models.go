@@ -1,9 +1,142 @@
 package usecases
 
+import (
+	"time"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/roles"
+)
+
 type UserModel struct {
 	ID int32
 	Login string
 	PasswordHash string
-	Role string
-	PermissionMask int64
+	Roles []string
+	PermissionMask roles.Mask
+	TokenVersion int32
+	// IsBanned tells whether the user is banned in OrgID.
+	IsBanned bool
+	// OrgID is the organization the roles are held in.
+	OrgID int32
+	// GrantExpiresAt is the earliest expiry of the temporary roles of the
+	// user, zero when all roles are permanent.
+	GrantExpiresAt time.Time
+}
+
+type RefreshTokenModel struct {
+	UserID   int32
+	OrgID    int32
+	ClientID string
+}
+
+type TokenSubject struct {
+	UserID         int32
+	Roles          []string
+	PermissionMask roles.Mask
+	TokenVersion   int32
+	ClientID       string
+	OrgID          int32
+	// NotAfter caps the token expiry when set.
+	NotAfter time.Time
+}
+
+// IsService reports whether the subject is a machine client authenticated
+// by the client credentials grant rather than a user.
+func (s *TokenSubject) IsService() bool {
+	return s.UserID == 0 && s.ClientID != ""
+}
+
+// UserSort orders user listings. A leading "-" sorts descending.
+type UserSort string
+
+const (
+	SortByLogin         UserSort = "login"
+	SortByLoginDesc     UserSort = "-login"
+	SortByCreatedAt     UserSort = "createdAt"
+	SortByCreatedAtDesc UserSort = "-createdAt"
+)
+
+// UserCursor is the last user of a page, the next page starts after it.
+type UserCursor struct {
+	ID        int32
+	Login     string
+	CreatedAt time.Time
+}
+
+// UserFilter selects users of an organization. Zero fields select all.
+type UserFilter struct {
+	OrgID       int32
+	Role        string
+	LoginPrefix string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	Sort        UserSort
+	After       *UserCursor
+	Limit       int
+}
+
+type UserListItemModel struct {
+	ID        int32
+	Login     string
+	CreatedAt time.Time
+	IsBanned  bool
+}
+
+type OrganizationModel struct {
+	ID        int32
+	Alias     string
+	Name      string
+	IsDefault bool
+}
+
+// RoleGrantModel is a temporary role assignment.
+type RoleGrantModel struct {
+	UserID    int32
+	Login     string
+	Role      string
+	GrantedAt time.Time
+	ExpiresAt time.Time
+}
+
+// RoleModel is a role with the permissions it grants. OwnPermissionMask are
+// the permissions given to the role itself, PermissionMask adds the ones
+// inherited in config. Overridden roles were changed through the API.
+type RoleModel struct {
+	ID                int32
+	Alias             string
+	PermissionMask    roles.Mask
+	OwnPermissionMask roles.Mask
+	IsDefault         bool
+	IsSuper           bool
+	Overridden        bool
+}
+
+// ClientModel is an OAuth 2.0 client. Confidential clients have a secret and
+// a role whose permissions they get in the client credentials grant.
+type ClientModel struct {
+	ClientID       string
+	RedirectURIs   []string
+	Audiences      []string
+	SecretHash     string
+	Role           string
+	PermissionMask roles.Mask
+}
+
+type AuthorizationCodeModel struct {
+	CodeHash      string
+	ClientID      string
+	UserID        int32
+	RedirectURI   string
+	CodeChallenge string
+	Scope         string
+	Nonce         string
+	AuthTime      time.Time
+	ExpiresAt     time.Time
+}
+
+type IDTokenSubject struct {
+	UserID   int32
+	Login    string
+	ClientID string
+	Nonce    string
+	AuthTime time.Time
 }
\ No newline at end of file
@@ -0,0 +1,412 @@
+package usecases
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	authorizationCodeBytes = 32
+
+	// openIDScope turns an OAuth 2.0 authorization request into an OpenID
+	// Connect authentication request.
+	openIDScope = "openid"
+)
+
+type ClientStorage interface {
+	UpsertClient(
+		ctx context.Context,
+		client *ClientModel,
+	) (err error)
+
+	GetClient(
+		ctx context.Context,
+		clientID string,
+	) (client *ClientModel, err error)
+
+	CreateAuthorizationCode(
+		ctx context.Context,
+		code *AuthorizationCodeModel,
+	) (err error)
+
+	ConsumeAuthorizationCode(
+		ctx context.Context,
+		codeHash string,
+	) (code *AuthorizationCodeModel, err error)
+}
+
+type IDTokenGenerator interface {
+	IDToken(
+		subject *IDTokenSubject,
+	) (string, error)
+}
+
+// OAuth implements the OAuth 2.0 authorization code grant with PKCE on top
+// of the credential check and token issuing of Usecase.
+type OAuth struct {
+	log            *slog.Logger
+	users          *Usecase
+	storage        ClientStorage
+	idTokens       IDTokenGenerator
+	accessTokenTTL time.Duration
+	codeTTL        time.Duration
+}
+
+func NewOAuth(
+	log *slog.Logger,
+	users *Usecase,
+	storage ClientStorage,
+	idTokens IDTokenGenerator,
+	accessTokenTTL time.Duration,
+	codeTTL time.Duration,
+) *OAuth {
+	return &OAuth{
+		log:            log,
+		users:          users,
+		storage:        storage,
+		idTokens:       idTokens,
+		accessTokenTTL: accessTokenTTL,
+		codeTTL:        codeTTL,
+	}
+}
+
+func (o *OAuth) RegisterClient(
+	ctx context.Context,
+	client *ClientModel,
+) (err error) {
+	const src = "OAuth.RegisterClient"
+	log := o.log.With(slog.String("src", src))
+
+	if client.SecretHash != "" {
+		if _, err := bcrypt.Cost([]byte(client.SecretHash)); err != nil {
+			return fmt.Errorf("%s: %s client secret is not a bcrypt hash: %w", src, client.ClientID, err)
+		}
+	}
+
+	err = o.storage.UpsertClient(ctx, client)
+	if err != nil {
+		return fmt.Errorf("%s: failed to save %s client: %w", src, client.ClientID, err)
+	}
+
+	if client.Role != "" {
+		saved, err := o.storage.GetClient(ctx, client.ClientID)
+		if err != nil {
+			return fmt.Errorf("%s: failed to get %s client: %w", src, client.ClientID, err)
+		}
+
+		if saved.Role != client.Role {
+			return fmt.Errorf("%s: %s client has unknown role %q", src, client.ClientID, client.Role)
+		}
+	}
+
+	log.Info("client indexed",
+		slog.String("client_id", client.ClientID),
+		slog.Int("redirect_uris", len(client.RedirectURIs)),
+	)
+
+	return nil
+}
+
+// ValidateAuthorization checks that the client exists and the redirect uri is
+// one of its registered ones. Until it passes, errors must not be reported by
+// redirecting to the uri.
+func (o *OAuth) ValidateAuthorization(
+	ctx context.Context,
+	req *AuthorizationRequest,
+) (err error) {
+	const src = "OAuth.ValidateAuthorization"
+
+	client, err := o.storage.GetClient(ctx, req.ClientID)
+	if err != nil {
+		if errors.Is(err, e.ErrNotFound) {
+			return fmt.Errorf("%s: %w", src, e.ErrUnknownClient)
+		}
+
+		return fmt.Errorf("%s: failed to get client: %w", src, err)
+	}
+
+	if !slices.Contains(client.RedirectURIs, req.RedirectURI) {
+		return fmt.Errorf("%s: %w", src, e.ErrInvalidRedirectURI)
+	}
+
+	return nil
+}
+
+func (o *OAuth) Authorize(
+	ctx context.Context,
+	req *AuthorizeRequest,
+) (resp *AuthorizeResponse, err error) {
+	const src = "OAuth.Authorize"
+	log := o.log.With(slog.String("src", src))
+	log.Debug("authorizing client", slog.String("client_id", req.ClientID))
+
+	if err := o.ValidateAuthorization(ctx, req.AuthorizationRequest); err != nil {
+		return nil, err
+	}
+
+	user, err := o.users.authenticate(ctx, req.Login, req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", src, err)
+	}
+
+	code, err := randomToken(authorizationCodeBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to generate code: %w", src, err)
+	}
+
+	now := time.Now()
+	err = o.storage.CreateAuthorizationCode(ctx, &AuthorizationCodeModel{
+		CodeHash:      hashToken(code),
+		ClientID:      req.ClientID,
+		UserID:        user.ID,
+		RedirectURI:   req.RedirectURI,
+		CodeChallenge: req.CodeChallenge,
+		Scope:         req.Scope,
+		Nonce:         req.Nonce,
+		AuthTime:      now,
+		ExpiresAt:     now.Add(o.codeTTL),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to save code: %w", src, err)
+	}
+
+	return &AuthorizeResponse{
+		Code: code,
+	}, nil
+}
+
+func (o *OAuth) ExchangeCode(
+	ctx context.Context,
+	req *ExchangeCodeRequest,
+) (resp *TokenResponse, err error) {
+	const src = "OAuth.ExchangeCode"
+	log := o.log.With(slog.String("src", src))
+	log.Debug("exchanging authorization code", slog.String("client_id", req.ClientID))
+
+	err = o.checkClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", src, err)
+	}
+
+	code, err := o.storage.ConsumeAuthorizationCode(ctx, hashToken(req.Code))
+	if err != nil {
+		if errors.Is(err, e.ErrNotFound) {
+			return nil, fmt.Errorf("%s: unknown code: %w", src, e.ErrInvalidGrant)
+		}
+
+		return nil, fmt.Errorf("%s: failed to get code: %w", src, err)
+	}
+
+	if time.Now().After(code.ExpiresAt) {
+		return nil, fmt.Errorf("%s: code expired: %w", src, e.ErrInvalidGrant)
+	}
+
+	if code.ClientID != req.ClientID || code.RedirectURI != req.RedirectURI {
+		return nil, fmt.Errorf("%s: code issued for another client: %w", src, e.ErrInvalidGrant)
+	}
+
+	if !verifyCodeChallenge(req.CodeVerifier, code.CodeChallenge) {
+		return nil, fmt.Errorf("%s: code verifier mismatch: %w", src, e.ErrInvalidGrant)
+	}
+
+	user, err := o.users.storage.GetUserById(ctx, 0, code.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get user: %w", src, err)
+	}
+
+	if user.IsBanned {
+		return nil, e.ErrUserBanned
+	}
+
+	token, err := o.users.tokenGenerator.Token(newTokenSubject(user, code.ClientID))
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to generate token: %w", src, err)
+	}
+
+	refreshToken, err := o.users.issueRefreshToken(ctx, user.ID, user.OrgID, code.ClientID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to issue refresh token: %w", src, err)
+	}
+
+	var idToken string
+	if slices.Contains(strings.Fields(code.Scope), openIDScope) {
+		idToken, err = o.idTokens.IDToken(&IDTokenSubject{
+			UserID:   user.ID,
+			Login:    user.Login,
+			ClientID: code.ClientID,
+			Nonce:    code.Nonce,
+			AuthTime: code.AuthTime,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to generate id token: %w", src, err)
+		}
+	}
+
+	return &TokenResponse{
+		UserID:       user.ID,
+		AccessToken:  token,
+		RefreshToken: refreshToken,
+		IDToken:      idToken,
+		ExpiresIn:    int(o.accessTokenTTL.Seconds()),
+		Scope:        code.Scope,
+	}, nil
+}
+
+// RefreshToken rotates a refresh token. Tokens bound to a client are only
+// redeemed once that client is authenticated.
+func (o *OAuth) RefreshToken(
+	ctx context.Context,
+	req *RefreshRequest,
+) (resp *TokenResponse, err error) {
+	const src = "OAuth.RefreshToken"
+
+	if req.ClientID != "" {
+		err = o.checkClient(ctx, req.ClientID, req.ClientSecret)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", src, err)
+		}
+	}
+
+	refreshed, err := o.users.Refresh(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", src, err)
+	}
+
+	return &TokenResponse{
+		UserID:       refreshed.ID,
+		AccessToken:  refreshed.Token,
+		RefreshToken: refreshed.RefreshToken,
+		ExpiresIn:    int(o.accessTokenTTL.Seconds()),
+	}, nil
+}
+
+// ClientCredentials issues a token to a machine client on its own behalf. The
+// token carries the permissions of the client role and no refresh token.
+func (o *OAuth) ClientCredentials(
+	ctx context.Context,
+	req *ClientCredentialsRequest,
+) (resp *TokenResponse, err error) {
+	const src = "OAuth.ClientCredentials"
+	log := o.log.With(slog.String("src", src))
+	log.Debug("issuing client token", slog.String("client_id", req.ClientID))
+
+	client, err := o.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", src, err)
+	}
+
+	if client.Role == "" {
+		return nil, fmt.Errorf("%s: client has no role: %w", src, e.ErrUnauthorizedClient)
+	}
+
+	token, err := o.users.tokenGenerator.Token(&TokenSubject{
+		Roles:          []string{client.Role},
+		PermissionMask: client.PermissionMask,
+		ClientID:       client.ClientID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to generate token: %w", src, err)
+	}
+
+	return &TokenResponse{
+		AccessToken: token,
+		ExpiresIn:   int(o.accessTokenTTL.Seconds()),
+		Scope:       req.Scope,
+	}, nil
+}
+
+// AuthenticateClient checks the credentials of a confidential client calling
+// an endpoint on its own behalf.
+func (o *OAuth) AuthenticateClient(
+	ctx context.Context,
+	req *ClientCredentialsRequest,
+) (err error) {
+	const src = "OAuth.AuthenticateClient"
+
+	_, err = o.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return fmt.Errorf("%s: %w", src, err)
+	}
+
+	return nil
+}
+
+// authenticateClient checks the secret of a confidential client. Unknown
+// clients, public clients and wrong secrets are reported alike.
+func (o *OAuth) authenticateClient(
+	ctx context.Context,
+	clientID string,
+	secret string,
+) (*ClientModel, error) {
+	client, err := o.storage.GetClient(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, e.ErrNotFound) {
+			return nil, e.ErrUnknownClient
+		}
+
+		return nil, fmt.Errorf("failed to get client: %w", err)
+	}
+
+	if client.SecretHash == "" {
+		return nil, e.ErrUnknownClient
+	}
+
+	err = checkClientSecret(client, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// checkClient authenticates the client a grant is redeemed by. Confidential
+// clients must present their secret, public ones are known by their id alone
+// and rely on PKCE or the refresh token binding instead.
+func (o *OAuth) checkClient(
+	ctx context.Context,
+	clientID string,
+	secret string,
+) error {
+	client, err := o.storage.GetClient(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, e.ErrNotFound) {
+			return e.ErrUnknownClient
+		}
+
+		return fmt.Errorf("failed to get client: %w", err)
+	}
+
+	if client.SecretHash == "" {
+		return nil
+	}
+
+	return checkClientSecret(client, secret)
+}
+
+func checkClientSecret(client *ClientModel, secret string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(client.SecretHash), []byte(secret))
+	if err != nil {
+		return e.ErrUnknownClient
+	}
+
+	return nil
+}
+
+// verifyCodeChallenge implements the RFC 7636 S256 transformation.
+func verifyCodeChallenge(verifier string, challenge string) bool {
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
@@ -0,0 +1,32 @@
+package usecases
+
+import "testing"
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	// The S256 example of RFC 7636 appendix B.
+	const (
+		verifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+		challenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	)
+
+	tests := []struct {
+		name      string
+		verifier  string
+		challenge string
+		want      bool
+	}{
+		{"matching verifier", verifier, challenge, true},
+		{"other verifier", verifier[1:], challenge, false},
+		{"plain challenge", verifier, verifier, false},
+		{"padded challenge", verifier, challenge + "=", false},
+		{"empty challenge", verifier, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyCodeChallenge(tt.verifier, tt.challenge); got != tt.want {
+				t.Errorf("verifyCodeChallenge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+)
+
+// OrganizationStorage keeps the tenants users and their roles belong to. A
+// user is a member of the organizations it holds roles in.
+type OrganizationStorage interface {
+	UpsertOrganization(
+		ctx context.Context,
+		alias string,
+		name string,
+	) (err error)
+
+	ListUserOrganizations(
+		ctx context.Context,
+		userID int32,
+	) (organizations []*OrganizationModel, err error)
+}
+
+func (u *Usecase) RegisterOrganization(
+	ctx context.Context,
+	alias string,
+	name string,
+) (err error) {
+	const src = "Usecase.RegisterOrganization"
+	log := u.log.With(slog.String("src", src))
+
+	if !roleAlias.MatchString(alias) {
+		return fmt.Errorf("%s: invalid organization alias %q", src, alias)
+	}
+
+	err = u.organizations.UpsertOrganization(ctx, alias, name)
+	if err != nil {
+		return fmt.Errorf("%s: failed to save %s organization: %w", src, alias, err)
+	}
+
+	log.Info("organization indexed", slog.String("alias", alias))
+
+	return nil
+}
+
+// ListOrganizations lists the organizations the caller is a member of.
+func (u *Usecase) ListOrganizations(
+	ctx context.Context,
+	req *ListOrganizationsRequest,
+) (*ListOrganizationsResponse, error) {
+	const src = "Usecase.ListOrganizations"
+	log := u.log.With(slog.String("src", src))
+	log.Debug("listing organizations", slog.Int("id", int(req.UserID)))
+
+	organizations, err := u.organizations.ListUserOrganizations(ctx, req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to list organizations: %w", src, err)
+	}
+
+	resp := &ListOrganizationsResponse{
+		Organizations: make([]*OrganizationResponse, 0, len(organizations)),
+	}
+	for _, org := range organizations {
+		resp.Organizations = append(resp.Organizations, &OrganizationResponse{
+			ID:       org.ID,
+			Alias:    org.Alias,
+			Name:     org.Name,
+			IsActive: org.ID == req.OrgID,
+		})
+	}
+
+	return resp, nil
+}
+
+// SwitchOrganization issues new tokens carrying the roles of the user in
+// another organization it is a member of. The token presented is revoked so
+// that it stops acting in the organization it was issued for.
+func (u *Usecase) SwitchOrganization(
+	ctx context.Context,
+	req *SwitchOrganizationRequest,
+) (*SwitchOrganizationResponse, error) {
+	const src = "Usecase.SwitchOrganization"
+	log := u.log.With(slog.String("src", src))
+	log.Debug("switching organization", slog.Int("id", int(req.UserID)), slog.Int("org_id", int(req.OrgID)))
+
+	user, err := u.storage.GetUserById(ctx, req.OrgID, req.UserID)
+	if err != nil {
+		if errors.Is(err, e.ErrNotFound) {
+			return nil, fmt.Errorf("%s: %w", src, e.ErrNotMember)
+		}
+
+		return nil, fmt.Errorf("%s: failed to get user: %w", src, err)
+	}
+
+	if user.IsBanned {
+		return nil, e.ErrUserBanned
+	}
+
+	token, err := u.tokenGenerator.Token(newTokenSubject(user, req.ClientID))
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to generate token: %w", src, err)
+	}
+
+	refreshToken, err := u.issueRefreshToken(ctx, user.ID, user.OrgID, req.ClientID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to issue refresh token: %w", src, err)
+	}
+
+	err = u.revocations.Revoke(ctx, req.TokenID, req.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to revoke token: %w", src, err)
+	}
+
+	return &SwitchOrganizationResponse{
+		ID:           user.ID,
+		Token:        token,
+		RefreshToken: refreshToken,
+	}, nil
+}
@@ -0,0 +1,50 @@
+package usecases
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/policy"
+)
+
+// Actions and resource types the usecases ask the policy about.
+const (
+	ReadAction = "read"
+
+	UserResource = "user"
+)
+
+// CheckAccess evaluates the policy for a subject described by the caller.
+// It lets other services share the rules of this one.
+func (u *Usecase) CheckAccess(
+	ctx context.Context,
+	req *CheckAccessRequest,
+) (*CheckAccessResponse, error) {
+	const src = "Usecase.CheckAccess"
+	log := u.log.With(slog.String("src", src))
+
+	decision := u.policy.Authorize(
+		policy.Subject{
+			UserID:      req.SubjectID,
+			Roles:       req.SubjectRoles,
+			Permissions: req.Permissions,
+		},
+		req.Action,
+		policy.Resource{
+			Type:    req.ResourceType,
+			OwnerID: req.OwnerID,
+		},
+	)
+
+	log.Debug("access checked",
+		slog.String("action", req.Action),
+		slog.String("resource", req.ResourceType),
+		slog.Bool("allowed", decision.Allowed),
+		slog.String("rule", decision.Rule),
+	)
+
+	return &CheckAccessResponse{
+		Allowed: decision.Allowed,
+		Rule:    decision.Rule,
+	}, nil
+}
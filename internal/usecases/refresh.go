@@ -0,0 +1,132 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+)
+
+const (
+	refreshTokenBytes = 32
+	familyIDBytes     = 16
+)
+
+func (u *Usecase) Refresh(
+	ctx context.Context,
+	req *RefreshRequest,
+) (resp *RefreshResponse, err error) {
+	const src = "Usecase.Refresh"
+	log := u.log.With(slog.String("src", src))
+	log.Debug("refreshing tokens")
+
+	refreshToken, err := randomToken(refreshTokenBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to generate refresh token: %w", src, err)
+	}
+
+	rotated, err := u.refreshTokens.RotateRefreshToken(
+		ctx,
+		hashToken(req.RefreshToken),
+		hashToken(refreshToken),
+		req.ClientID,
+		time.Now().Add(u.refreshTokenTTL),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to rotate refresh token: %w", src, err)
+	}
+
+	user, err := u.storage.GetUserById(ctx, rotated.OrgID, rotated.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get user: %w", src, err)
+	}
+
+	if user.IsBanned {
+		return nil, e.ErrUserBanned
+	}
+
+	token, err := u.tokenGenerator.Token(newTokenSubject(user, rotated.ClientID))
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to generate token: %w", src, err)
+	}
+
+	return &RefreshResponse{
+		ID:           user.ID,
+		Token:        token,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+func (u *Usecase) Logout(
+	ctx context.Context,
+	req *LogoutRequest,
+) (err error) {
+	const src = "Usecase.Logout"
+	log := u.log.With(slog.String("src", src))
+	log.Debug("logout", slog.String("jti", req.TokenID))
+
+	err = u.revocations.Revoke(ctx, req.TokenID, req.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("%s: failed to revoke token: %w", src, err)
+	}
+
+	if req.RefreshToken != "" {
+		err = u.refreshTokens.RevokeRefreshTokenFamily(ctx, hashToken(req.RefreshToken))
+		if err != nil {
+			return fmt.Errorf("%s: failed to revoke refresh token: %w", src, err)
+		}
+	}
+
+	return nil
+}
+
+// issueRefreshToken starts a new refresh token family for the user, keeping
+// the active organization across refreshes.
+func (u *Usecase) issueRefreshToken(ctx context.Context, userID int32, orgID int32, clientID string) (string, error) {
+	familyID, err := randomToken(familyIDBytes)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := randomToken(refreshTokenBytes)
+	if err != nil {
+		return "", err
+	}
+
+	err = u.refreshTokens.CreateRefreshToken(
+		ctx,
+		userID,
+		orgID,
+		clientID,
+		familyID,
+		hashToken(token),
+		time.Now().Add(u.refreshTokenTTL),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken returns the form opaque tokens are stored in, so a database leak
+// does not expose usable tokens.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,39 @@
+package usecases
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/revocation"
+)
+
+func TestLogoutRevokesToken(t *testing.T) {
+	ctx := context.Background()
+	store := revocation.NewMemoryStore()
+
+	u := New(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		nil, nil, nil, nil, nil, nil, nil, store, nil, nil, 0,
+	)
+
+	req, err := NewLogoutRequest("jti", time.Now().Add(time.Hour), "")
+	if err != nil {
+		t.Fatalf("NewLogoutRequest: %v", err)
+	}
+
+	if err := u.Logout(ctx, req); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+
+	revoked, err := store.IsRevoked(ctx, "jti")
+	if err != nil {
+		t.Fatalf("IsRevoked: %v", err)
+	}
+
+	if !revoked {
+		t.Error("token is not revoked after logout")
+	}
+}
@@ -0,0 +1,297 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/roles"
+)
+
+type RoleStorage interface {
+	ListRoles(
+		ctx context.Context,
+	) (roles []*RoleModel, err error)
+
+	GetRole(
+		ctx context.Context,
+		alias string,
+	) (role *RoleModel, err error)
+
+	// The permissions of a saved role are its own ones. Saving or deleting
+	// a role updates the roles of hierarchy inheriting from it.
+	CreateRole(
+		ctx context.Context,
+		role *RoleModel,
+		hierarchy []roles.ResolvedRole,
+	) (err error)
+
+	UpdateRole(
+		ctx context.Context,
+		role *RoleModel,
+		hierarchy []roles.ResolvedRole,
+	) (err error)
+
+	DeleteRole(
+		ctx context.Context,
+		alias string,
+		reassignTo string,
+		hierarchy []roles.ResolvedRole,
+	) (err error)
+}
+
+// RoleHierarchy provides the roles declared in config resolved with their
+// inherited permissions.
+type RoleHierarchy interface {
+	Hierarchy() []roles.ResolvedRole
+}
+
+func (u *Usecase) ListRoles(
+	ctx context.Context,
+	req *ListRolesRequest,
+) (*ListRolesResponse, error) {
+	const src = "Usecase.ListRoles"
+	log := u.log.With(slog.String("src", src))
+	log.Debug("listing roles")
+
+	if !roles.HasPermission(req.PermissionMask, roles.CanManageRoles) {
+		return nil, e.ErrForbiddenAction
+	}
+
+	models, err := u.roles.ListRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to list roles: %w", src, err)
+	}
+
+	resp := &ListRolesResponse{
+		Roles: make([]*RoleResponse, 0, len(models)),
+	}
+	for _, role := range models {
+		resp.Roles = append(resp.Roles, newRoleResponse(role))
+	}
+
+	return resp, nil
+}
+
+func (u *Usecase) GetRole(
+	ctx context.Context,
+	req *GetRoleRequest,
+) (*RoleResponse, error) {
+	const src = "Usecase.GetRole"
+	log := u.log.With(slog.String("src", src))
+	log.Debug("get role", slog.String("alias", req.Alias))
+
+	if !roles.HasPermission(req.PermissionMask, roles.CanManageRoles) {
+		return nil, e.ErrForbiddenAction
+	}
+
+	role, err := u.roles.GetRole(ctx, req.Alias)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get role: %w", src, err)
+	}
+
+	return newRoleResponse(role), nil
+}
+
+func (u *Usecase) CreateRole(
+	ctx context.Context,
+	req *SaveRoleRequest,
+) (*RoleResponse, error) {
+	const src = "Usecase.CreateRole"
+	log := u.log.With(slog.String("src", src))
+	log.Debug("creating role", slog.String("alias", req.Role.Alias))
+
+	if !roles.HasPermission(req.PermissionMask, roles.CanManageRoles) {
+		return nil, e.ErrForbiddenAction
+	}
+
+	if err := u.requireSuperUser(ctx, req.Roles); err != nil {
+		return nil, fmt.Errorf("%s: %w", src, err)
+	}
+
+	if !req.PermissionMask.Contains(req.Role.PermissionMask) {
+		return nil, e.ErrRoleNotGrantable
+	}
+
+	err := u.roles.CreateRole(ctx, req.Role, u.hierarchy.Hierarchy())
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to create role: %w", src, err)
+	}
+
+	// Roles of config may inherit from a role deleted and created again.
+	u.tokenStates.InvalidateAll()
+
+	return u.savedRole(ctx, req.Role.Alias)
+}
+
+// UpdateRole replaces the permissions and flags of a role. Roles declared in
+// config keep the change over config from then on.
+func (u *Usecase) UpdateRole(
+	ctx context.Context,
+	req *SaveRoleRequest,
+) (*RoleResponse, error) {
+	const src = "Usecase.UpdateRole"
+	log := u.log.With(slog.String("src", src))
+	log.Debug("updating role", slog.String("alias", req.Role.Alias))
+
+	if !roles.HasPermission(req.PermissionMask, roles.CanManageRoles) {
+		return nil, e.ErrForbiddenAction
+	}
+
+	if err := u.requireSuperUser(ctx, req.Roles); err != nil {
+		return nil, fmt.Errorf("%s: %w", src, err)
+	}
+
+	if !req.PermissionMask.Contains(req.Role.PermissionMask) {
+		return nil, e.ErrRoleNotGrantable
+	}
+
+	err := u.roles.UpdateRole(ctx, req.Role, u.hierarchy.Hierarchy())
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to update role: %w", src, err)
+	}
+
+	u.tokenStates.InvalidateAll()
+
+	return u.savedRole(ctx, req.Role.Alias)
+}
+
+// DeleteRole deletes a role. A role declared in config is created again, with
+// the permissions declared there, on the next start.
+
+func (u *Usecase) DeleteRole(
+	ctx context.Context,
+	req *DeleteRoleRequest,
+) (err error) {
+	const src = "Usecase.DeleteRole"
+	log := u.log.With(slog.String("src", src))
+	log.Debug("deleting role", slog.String("alias", req.Alias))
+
+	if !roles.HasPermission(req.PermissionMask, roles.CanManageRoles) {
+		return e.ErrForbiddenAction
+	}
+
+	if err := u.requireSuperUser(ctx, req.Roles); err != nil {
+		return fmt.Errorf("%s: %w", src, err)
+	}
+
+	role, err := u.roles.GetRole(ctx, req.Alias)
+	if err != nil {
+		return fmt.Errorf("%s: failed to get role: %w", src, err)
+	}
+
+	// New users get the default role, it cannot go away.
+	if role.IsDefault {
+		return fmt.Errorf("%s: role is the default one: %w", src, e.ErrRoleInUse)
+	}
+
+	// Moving super users to another role would demote them all.
+	if role.IsSuper {
+		return fmt.Errorf("%s: %w", src, e.ErrSuperRoleDeletion)
+	}
+
+	err = u.roles.DeleteRole(ctx, req.Alias, req.ReassignTo, u.hierarchy.Hierarchy())
+	if err != nil {
+		return fmt.Errorf("%s: failed to delete role: %w", src, err)
+	}
+
+	// Reassigned users and the roles inheriting from the deleted one have
+	// other permissions now.
+	u.tokenStates.InvalidateAll()
+
+	return nil
+}
+
+// RoleHierarchy describes how the roles declared in config inherit from each
+// other and the permissions they end up with, changes made through the API
+// included. Roles deleted through the API are left out.
+func (u *Usecase) RoleHierarchy(
+	ctx context.Context,
+	req *RoleHierarchyRequest,
+) (*RoleHierarchyResponse, error) {
+	const src = "Usecase.RoleHierarchy"
+	log := u.log.With(slog.String("src", src))
+	log.Debug("get role hierarchy")
+
+	if !roles.HasPermission(req.PermissionMask, roles.CanManageRoles) {
+		return nil, e.ErrForbiddenAction
+	}
+
+	models, err := u.roles.ListRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to list roles: %w", src, err)
+	}
+
+	stored := make(map[string]*RoleModel, len(models))
+	for _, role := range models {
+		stored[role.Alias] = role
+	}
+
+	hierarchy := u.hierarchy.Hierarchy()
+
+	resp := &RoleHierarchyResponse{
+		Roles: make([]*ResolvedRoleResponse, 0, len(hierarchy)),
+	}
+	for _, role := range hierarchy {
+		model, ok := stored[role.Alias]
+		if !ok {
+			continue
+		}
+
+		resp.Roles = append(resp.Roles, &ResolvedRoleResponse{
+			Alias:                role.Alias,
+			Inherits:             role.Inherits,
+			Ancestors:            role.Ancestors,
+			Permissions:          roles.PermissionNames(model.OwnPermissionMask),
+			EffectivePermissions: roles.PermissionNames(model.PermissionMask),
+			IsDefault:            model.IsDefault,
+			IsSuper:              model.IsSuper,
+			Overridden:           model.Overridden,
+		})
+	}
+
+	return resp, nil
+}
+
+// savedRole returns a role as stored, with its inherited permissions.
+func (u *Usecase) savedRole(ctx context.Context, alias string) (*RoleResponse, error) {
+	role, err := u.roles.GetRole(ctx, alias)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+
+	return newRoleResponse(role), nil
+}
+
+// requireSuperUser lets only holders of the super role through. Roles are
+// shared by every organization, so the admins of one may not change them.
+func (u *Usecase) requireSuperUser(ctx context.Context, aliases []string) error {
+	for _, alias := range aliases {
+		role, err := u.roles.GetRole(ctx, alias)
+		if err != nil {
+			if errors.Is(err, e.ErrNotFound) {
+				continue
+			}
+
+			return fmt.Errorf("failed to get role: %w", err)
+		}
+
+		if role.IsSuper {
+			return nil
+		}
+	}
+
+	return e.ErrForbiddenAction
+}
+
+func newRoleResponse(role *RoleModel) *RoleResponse {
+	return &RoleResponse{
+		Alias:       role.Alias,
+		Permissions: roles.PermissionNames(role.PermissionMask),
+		IsDefault:   role.IsDefault,
+		IsSuper:     role.IsSuper,
+		Overridden:  role.Overridden,
+	}
+}
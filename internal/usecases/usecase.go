@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/policy"
 	"github.com/AleksandrVishniakov/jwt-auth/internal/roles"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -26,6 +28,7 @@ type UserStorage interface {
 
 	GetUserById(
 		ctx context.Context,
+		orgID int32,
 		id int32,
 	) (user *UserModel, err error)
 
@@ -34,36 +37,150 @@ type UserStorage interface {
 		login string,
 	) (user *UserModel, err error)
 
-	UpdateRoleById(
+	AssignRole(
 		ctx context.Context,
+		orgID int32,
 		userID int32,
-		roleAlias string,
+		roleID int32,
+		expiresAt time.Time,
+	) (err error)
+
+	UnassignRole(
+		ctx context.Context,
+		orgID int32,
+		userID int32,
+		roleID int32,
+	) (err error)
+
+	ListRoleGrants(
+		ctx context.Context,
+		orgID int32,
+		now time.Time,
+	) (grants []*RoleGrantModel, err error)
+
+	SetUserBanned(
+		ctx context.Context,
+		orgID int32,
+		userID int32,
+		banned bool,
+	) (err error)
+
+	ListUsers(
+		ctx context.Context,
+		filter *UserFilter,
+	) (users []*UserListItemModel, err error)
+
+	ChangePassword(
+		ctx context.Context,
+		userID int32,
+		passwordHash []byte,
+	) (err error)
+}
+
+type RefreshTokenStorage interface {
+	CreateRefreshToken(
+		ctx context.Context,
+		userID int32,
+		orgID int32,
+		clientID string,
+		familyID string,
+		tokenHash string,
+		expiresAt time.Time,
+	) (err error)
+
+	RotateRefreshToken(
+		ctx context.Context,
+		oldHash string,
+		newHash string,
+		clientID string,
+		expiresAt time.Time,
+	) (token *RefreshTokenModel, err error)
+
+	RevokeRefreshTokenFamily(
+		ctx context.Context,
+		tokenHash string,
+	) (err error)
+}
+
+type TokenRevoker interface {
+	Revoke(
+		ctx context.Context,
+		jti string,
+		expiresAt time.Time,
 	) (err error)
 }
 
 type TokenGenerator interface {
 	Token(
-		userID int32,
-		role string,
-		permissionMask int64,
+		subject *TokenSubject,
 	) (string, error)
+
+	ValidateClient(
+		clientID string,
+	) error
+}
+
+type KeyRotator interface {
+	Rotate(ctx context.Context) (kid string, err error)
+}
+
+type TokenStateInvalidator interface {
+	Invalidate(userID int32)
+	InvalidateAll()
+}
+
+// Policy decides resource level access from subject and resource
+// attributes.
+type Policy interface {
+	Authorize(
+		subject policy.Subject,
+		action string,
+		resource policy.Resource,
+	) policy.Decision
 }
 
 type Usecase struct {
-	log            *slog.Logger
-	storage        UserStorage
-	tokenGenerator TokenGenerator
+	log             *slog.Logger
+	storage         UserStorage
+	roles           RoleStorage
+	organizations   OrganizationStorage
+	hierarchy       RoleHierarchy
+	refreshTokens   RefreshTokenStorage
+	tokenGenerator  TokenGenerator
+	keyRotator      KeyRotator
+	revocations     TokenRevoker
+	tokenStates     TokenStateInvalidator
+	policy          Policy
+	refreshTokenTTL time.Duration
 }
 
 func New(
 	log *slog.Logger,
 	storage UserStorage,
+	roles RoleStorage,
+	organizations OrganizationStorage,
+	hierarchy RoleHierarchy,
+	refreshTokens RefreshTokenStorage,
 	tokenGenerator TokenGenerator,
+	keyRotator KeyRotator,
+	revocations TokenRevoker,
+	tokenStates TokenStateInvalidator,
+	policy Policy,
+	refreshTokenTTL time.Duration,
 ) *Usecase {
 	return &Usecase{
-		log:            log,
-		storage:        storage,
-		tokenGenerator: tokenGenerator,
+		log:             log,
+		storage:         storage,
+		roles:           roles,
+		organizations:   organizations,
+		hierarchy:       hierarchy,
+		refreshTokens:   refreshTokens,
+		tokenGenerator:  tokenGenerator,
+		keyRotator:      keyRotator,
+		revocations:     revocations,
+		tokenStates:     tokenStates,
+		policy:          policy,
+		refreshTokenTTL: refreshTokenTTL,
 	}
 }
 
@@ -75,24 +192,29 @@ func (u *Usecase) Login(
 	log := u.log.With(slog.String("src", src))
 	log.Debug("login user")
 
-	user, err := u.storage.GetUserByLogin(ctx, req.Login)
-	if err != nil {
-		return nil, fmt.Errorf("%s: failed to get user: %w", src, err)
+	if err := u.tokenGenerator.ValidateClient(req.ClientID); err != nil {
+		return nil, fmt.Errorf("%s: %w", src, err)
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password))
+	user, err := u.authenticate(ctx, req.Login, req.Password)
 	if err != nil {
-		return nil, fmt.Errorf("%s: failed to compare password: %w", src, err)
+		return nil, fmt.Errorf("%s: %w", src, err)
 	}
 
-	token, err := u.tokenGenerator.Token(user.ID, user.Role, user.PermissionMask)
+	token, err := u.tokenGenerator.Token(newTokenSubject(user, req.ClientID))
 	if err != nil {
 		return nil, fmt.Errorf("%s: failed to generate token: %w", src, err)
 	}
 
+	refreshToken, err := u.issueRefreshToken(ctx, user.ID, user.OrgID, req.ClientID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to issue refresh token: %w", src, err)
+	}
+
 	return &LoginResponse{
-		ID:    user.ID,
-		Token: token,
+		ID:           user.ID,
+		Token:        token,
+		RefreshToken: refreshToken,
 	}, nil
 }
 
@@ -104,6 +226,10 @@ func (u *Usecase) Register(
 	log := u.log.With(slog.String("src", src))
 	log.Debug("register new user")
 
+	if err := u.tokenGenerator.ValidateClient(req.ClientID); err != nil {
+		return nil, fmt.Errorf("%s: %w", src, err)
+	}
+
 	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, fmt.Errorf("%s: failed to generate password hash: %w", src, err)
@@ -114,39 +240,248 @@ func (u *Usecase) Register(
 		return nil, fmt.Errorf("%s: failed to create new user: %w", src, err)
 	}
 
-	user, err := u.storage.GetUserById(ctx, id)
+	user, err := u.storage.GetUserById(ctx, 0, id)
 	if err != nil {
 		return nil, fmt.Errorf("%s: failed to get user role: %w", src, err)
 	}
 
-	token, err := u.tokenGenerator.Token(id, user.Role, user.PermissionMask)
+	token, err := u.tokenGenerator.Token(newTokenSubject(user, req.ClientID))
 	if err != nil {
 		return nil, fmt.Errorf("%s: failed to generate token: %w", src, err)
 	}
 
+	refreshToken, err := u.issueRefreshToken(ctx, id, user.OrgID, req.ClientID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to issue refresh token: %w", src, err)
+	}
+
 	return &RegisterResponse{
-		ID:    id,
-		Token: token,
+		ID:           id,
+		Token:        token,
+		RefreshToken: refreshToken,
 	}, nil
 }
 
-func (u *Usecase) UpdateRoleById(
+// AssignRole gives a role to a user in the active organization of the
+// caller, until req.ExpiresAt when it is set. Users outside the organization
+// join it. A temporary assignment does not shorten a permanent one.
+func (u *Usecase) AssignRole(
+	ctx context.Context,
+	req *UserRoleRequest,
+) (err error) {
+	const src = "Usecase.AssignRole"
+	log := u.log.With(slog.String("src", src))
+	log.Debug("assigning role", slog.Int("id", int(req.ProfileID)), slog.String("role", req.Role))
+
+	role, err := u.checkRoleChange(ctx, req, true)
+	if err != nil {
+		return fmt.Errorf("%s: %w", src, err)
+	}
+
+	err = u.storage.AssignRole(ctx, req.OrgID, req.ProfileID, role.ID, req.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, e.ErrNotFound) {
+			return fmt.Errorf("%s: %w", src, e.ErrUserNotFound)
+		}
+
+		return fmt.Errorf("%s: failed to assign role: %w", src, err)
+	}
+
+	u.tokenStates.Invalidate(req.ProfileID)
+
+	return nil
+}
+
+// UnassignRole takes a role from a user in the active organization of the
+// caller. A member keeps at least one role and the last super user of the
+// organization keeps the super role.
+func (u *Usecase) UnassignRole(
+	ctx context.Context,
+	req *UserRoleRequest,
+) (err error) {
+	const src = "Usecase.UnassignRole"
+	log := u.log.With(slog.String("src", src))
+	log.Debug("unassigning role", slog.Int("id", int(req.ProfileID)), slog.String("role", req.Role))
+
+	role, err := u.checkRoleChange(ctx, req, false)
+	if err != nil {
+		return fmt.Errorf("%s: %w", src, err)
+	}
+
+	err = u.storage.UnassignRole(ctx, req.OrgID, req.ProfileID, role.ID)
+	if err != nil {
+		if errors.Is(err, e.ErrRoleNotAssigned) ||
+			errors.Is(err, e.ErrLastRole) ||
+			errors.Is(err, e.ErrLastSuperUser) {
+			return fmt.Errorf("%s: %w", src, err)
+		}
+
+		if errors.Is(err, e.ErrNotFound) {
+			return fmt.Errorf("%s: %w", src, e.ErrUserNotFound)
+		}
+
+		return fmt.Errorf("%s: failed to unassign role: %w", src, err)
+	}
+
+	u.tokenStates.Invalidate(req.ProfileID)
+
+	return nil
+}
+
+// checkRoleChange guards role assignments against privilege escalation:
+// callers cannot change their own roles, grant or take roles with
+// permissions they lack, or change users having such permissions in the
+// organization. With joining, the user may be outside the organization, it
+// then must not hold such permissions in any organization it belongs to.
+func (u *Usecase) checkRoleChange(ctx context.Context, req *UserRoleRequest, joining bool) (*RoleModel, error) {
+	if !roles.HasPermission(req.PermissionMask, roles.CanUpdateUserRole) {
+		return nil, e.ErrForbiddenAction
+	}
+
+	if req.UserID == req.ProfileID {
+		return nil, e.ErrOwnRoles
+	}
+
+	role, err := u.getRole(ctx, req.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	if !req.PermissionMask.Contains(role.PermissionMask) {
+		return nil, e.ErrRoleNotGrantable
+	}
+
+	user, err := u.storage.GetUserById(ctx, req.OrgID, req.ProfileID)
+	if err != nil {
+		if errors.Is(err, e.ErrNotFound) {
+			if !joining {
+				return nil, e.ErrUserNotFound
+			}
+
+			if err := u.checkJoiningUser(ctx, req); err != nil {
+				return nil, err
+			}
+
+			return role, nil
+		}
+
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if !req.PermissionMask.Contains(user.PermissionMask) {
+		return nil, e.ErrUserMorePrivileged
+	}
+
+	return role, nil
+}
+
+// checkJoiningUser refuses to pull a user into the organization of the
+// caller when it holds permissions the caller lacks in another one.
+func (u *Usecase) checkJoiningUser(ctx context.Context, req *UserRoleRequest) error {
+	organizations, err := u.organizations.ListUserOrganizations(ctx, req.ProfileID)
+	if err != nil {
+		return fmt.Errorf("failed to list user organizations: %w", err)
+	}
+
+	for _, org := range organizations {
+		user, err := u.storage.GetUserById(ctx, org.ID, req.ProfileID)
+		if err != nil {
+			// The membership ended since the organizations were listed.
+			if errors.Is(err, e.ErrNotFound) {
+				continue
+			}
+
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+
+		if !req.PermissionMask.Contains(user.PermissionMask) {
+			return e.ErrUserMorePrivileged
+		}
+	}
+
+	return nil
+}
+
+// ListRoleGrants lists the temporary role assignments of the active
+// organization that did not expire.
+func (u *Usecase) ListRoleGrants(
 	ctx context.Context,
-	req *UpdateUserRoleRequest,
+	req *ListRoleGrantsRequest,
+) (*ListRoleGrantsResponse, error) {
+	const src = "Usecase.ListRoleGrants"
+	log := u.log.With(slog.String("src", src))
+	log.Debug("listing role grants")
+
+	if !roles.HasPermission(req.PermissionMask, roles.CanUpdateUserRole) {
+		return nil, e.ErrForbiddenAction
+	}
+
+	grants, err := u.storage.ListRoleGrants(ctx, req.OrgID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to list role grants: %w", src, err)
+	}
+
+	resp := &ListRoleGrantsResponse{
+		Grants: make([]*RoleGrantResponse, 0, len(grants)),
+	}
+	for _, grant := range grants {
+		resp.Grants = append(resp.Grants, &RoleGrantResponse{
+			UserID:    grant.UserID,
+			Login:     grant.Login,
+			Role:      grant.Role,
+			GrantedAt: grant.GrantedAt,
+			ExpiresAt: grant.ExpiresAt,
+		})
+	}
+
+	return resp, nil
+}
+
+func (u *Usecase) getRole(ctx context.Context, alias string) (*RoleModel, error) {
+	role, err := u.roles.GetRole(ctx, alias)
+	if err != nil {
+		if errors.Is(err, e.ErrNotFound) {
+			return nil, e.ErrRoleNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+
+	return role, nil
+}
+
+// SetUserBanned bans or unbans a member of the caller's organization. The ban
+// only locks the user out of that organization. Users of other organizations
+// are not found, and users holding permissions the caller lacks can't be
+// banned, just like their roles can't be changed.
+func (u *Usecase) SetUserBanned(
+	ctx context.Context,
+	req *SetUserBannedRequest,
 ) (err error) {
-	const src = "Usecase.UpdateRoleById"
+	const src = "Usecase.SetUserBanned"
 	log := u.log.With(slog.String("src", src))
-	log.Debug("updating user role", slog.Int("id", int(req.UserID)))
+	log.Debug("updating user ban", slog.Int("id", int(req.ProfileID)), slog.Bool("banned", req.Banned))
 
-	if !roles.HasPermission(req.PermissionsMask, roles.CanUpdateUserRole) {
+	if !roles.HasPermission(req.PermissionMask, roles.CanBanUsers) || req.UserID == req.ProfileID {
 		return e.ErrForbiddenAction
 	}
 
-	err = u.storage.UpdateRoleById(ctx, req.UserID, req.Role)
+	user, err := u.storage.GetUserById(ctx, req.OrgID, req.ProfileID)
 	if err != nil {
-		return fmt.Errorf("%s: failed to update role: %w", src, err)
+		return fmt.Errorf("%s: failed to get user: %w", src, err)
 	}
 
+	if !req.PermissionMask.Contains(user.PermissionMask) {
+		return e.ErrUserMorePrivileged
+	}
+
+	err = u.storage.SetUserBanned(ctx, req.OrgID, req.ProfileID, req.Banned)
+	if err != nil {
+		return fmt.Errorf("%s: failed to update user: %w", src, err)
+	}
+
+	u.tokenStates.Invalidate(req.ProfileID)
+
 	return nil
 }
 
@@ -180,11 +515,23 @@ func (u *Usecase) GetUserByID(
 	log := u.log.With(slog.String("src", src))
 	log.Debug("get user", slog.Int("id", int(req.ProfileID)))
 
-	if (req.UserID != req.ProfileID) && !roles.HasPermission(req.PermissionMask, roles.CanSeeProfiles) {
+	decision := u.policy.Authorize(
+		policy.Subject{
+			UserID:      req.UserID,
+			Roles:       req.Roles,
+			Permissions: req.PermissionMask,
+		},
+		ReadAction,
+		policy.Resource{
+			Type:    UserResource,
+			OwnerID: req.ProfileID,
+		},
+	)
+	if !decision.Allowed {
 		return nil, e.ErrForbiddenAction
 	}
 
-	entity, err := u.storage.GetUserById(ctx, req.ProfileID)
+	entity, err := u.storage.GetUserById(ctx, req.OrgID, req.ProfileID)
 	if err != nil {
 		return nil, fmt.Errorf("%s: failed to get user: %w", src, err)
 	}
@@ -192,6 +539,69 @@ func (u *Usecase) GetUserByID(
 	return &GetUserByIDResponse{
 		ID:    entity.ID,
 		Login: entity.Login,
-		Role:  entity.Role,
+		Roles: entity.Roles,
 	}, nil
 }
+
+func (u *Usecase) RotateSigningKey(
+	ctx context.Context,
+	req *RotateSigningKeyRequest,
+) (*RotateSigningKeyResponse, error) {
+	const src = "Usecase.RotateSigningKey"
+	log := u.log.With(slog.String("src", src))
+	log.Debug("rotating signing key")
+
+	if !roles.HasPermission(req.PermissionMask, roles.CanManageKeys) {
+		return nil, e.ErrForbiddenAction
+	}
+
+	kid, err := u.keyRotator.Rotate(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to rotate key: %w", src, err)
+	}
+
+	return &RotateSigningKeyResponse{
+		KeyID: kid,
+	}, nil
+}
+
+// authenticate checks the user credentials. It is shared by every flow that
+// accepts a login and password.
+func (u *Usecase) authenticate(
+	ctx context.Context,
+	login string,
+	password string,
+) (*UserModel, error) {
+	user, err := u.storage.GetUserByLogin(ctx, login)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	err = checkPassword(user, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare password: %w", err)
+	}
+
+	if user.IsBanned {
+		return nil, e.ErrUserBanned
+	}
+
+	return user, nil
+}
+
+// checkPassword compares a password with the hash stored for the user.
+func checkPassword(user *UserModel, password string) error {
+	return bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+}
+
+func newTokenSubject(user *UserModel, clientID string) *TokenSubject {
+	return &TokenSubject{
+		UserID:         user.ID,
+		Roles:          user.Roles,
+		PermissionMask: user.PermissionMask,
+		TokenVersion:   user.TokenVersion,
+		ClientID:       clientID,
+		OrgID:          user.OrgID,
+		NotAfter:       user.GrantExpiresAt,
+	}
+}
@@ -0,0 +1,235 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/roles"
+)
+
+// fakeUsers serves the members of each organization. Methods the tests do
+// not need panic through the nil embedded interface.
+type fakeUsers struct {
+	UserStorage
+	members map[int32]map[int32]*UserModel
+}
+
+func (f *fakeUsers) SetUserBanned(ctx context.Context, orgID int32, userID int32, banned bool) error {
+	user, ok := f.members[orgID][userID]
+	if !ok {
+		return e.ErrNotFound
+	}
+
+	user.IsBanned = banned
+	return nil
+}
+
+func (f *fakeUsers) GetUserById(ctx context.Context, orgID int32, id int32) (*UserModel, error) {
+	user, ok := f.members[orgID][id]
+	if !ok {
+		return nil, e.ErrNotFound
+	}
+
+	return user, nil
+}
+
+type fakeRoles struct {
+	RoleStorage
+	roles map[string]*RoleModel
+}
+
+func (f *fakeRoles) GetRole(ctx context.Context, alias string) (*RoleModel, error) {
+	role, ok := f.roles[alias]
+	if !ok {
+		return nil, e.ErrNotFound
+	}
+
+	return role, nil
+}
+
+type fakeTokenStates struct {
+	invalidated []int32
+}
+
+func (f *fakeTokenStates) Invalidate(userID int32) {
+	f.invalidated = append(f.invalidated, userID)
+}
+
+func (f *fakeTokenStates) InvalidateAll() {}
+
+type fakeOrganizations struct {
+	OrganizationStorage
+	users *fakeUsers
+}
+
+func (f *fakeOrganizations) ListUserOrganizations(ctx context.Context, userID int32) ([]*OrganizationModel, error) {
+	var organizations []*OrganizationModel
+	for orgID, members := range f.users.members {
+		if _, ok := members[userID]; ok {
+			organizations = append(organizations, &OrganizationModel{ID: orgID})
+		}
+	}
+
+	return organizations, nil
+}
+
+func TestCheckRoleChange(t *testing.T) {
+	const (
+		callerID = 1
+		memberID = 2
+		adminID  = 3
+		outsider = 4
+		stranger = 5
+		orgA     = 10
+		orgB     = 20
+	)
+
+	moderator := roles.NewMask(roles.CanUpdateUserRole, roles.CanBanUsers)
+	admin := moderator.With(roles.CanManageRoles)
+
+	users := &fakeUsers{members: map[int32]map[int32]*UserModel{
+		orgA: {
+			callerID: {ID: callerID, PermissionMask: moderator},
+			memberID: {ID: memberID},
+			adminID:  {ID: adminID, PermissionMask: admin},
+		},
+		orgB: {
+			outsider: {ID: outsider, PermissionMask: admin},
+			stranger: {ID: stranger},
+		},
+	}}
+
+	u := New(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		users,
+		&fakeRoles{roles: map[string]*RoleModel{
+			"user":      {Alias: "user"},
+			"moderator": {Alias: "moderator", PermissionMask: moderator},
+			"admin":     {Alias: "admin", PermissionMask: admin},
+		}},
+		&fakeOrganizations{users: users},
+		nil, nil, nil, nil, nil, nil, nil, 0,
+	)
+
+	tests := []struct {
+		name    string
+		mask    roles.Mask
+		profile int32
+		role    string
+		joining bool
+		wantErr error
+	}{
+		{"grants held permissions", moderator, memberID, "moderator", false, nil},
+		{"without permission", roles.NewMask(roles.CanBanUsers), memberID, "user", false, e.ErrForbiddenAction},
+		{"own roles", moderator, callerID, "user", false, e.ErrOwnRoles},
+		{"unknown role", moderator, memberID, "owner", false, e.ErrRoleNotFound},
+		{"role with more permissions", moderator, memberID, "admin", false, e.ErrRoleNotGrantable},
+		{"more privileged user", moderator, adminID, "user", false, e.ErrUserMorePrivileged},
+		{"user of another organization", moderator, stranger, "user", false, e.ErrUserNotFound},
+		{"joining user", moderator, stranger, "moderator", true, nil},
+		{"joining more privileged user", moderator, outsider, "user", true, e.ErrUserMorePrivileged},
+		{"joining with role with more permissions", moderator, stranger, "admin", true, e.ErrRoleNotGrantable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &UserRoleRequest{
+				UserID:         callerID,
+				OrgID:          orgA,
+				PermissionMask: tt.mask,
+				ProfileID:      tt.profile,
+				Role:           tt.role,
+			}
+
+			role, err := u.checkRoleChange(context.Background(), req, tt.joining)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("checkRoleChange() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && role.Alias != tt.role {
+				t.Errorf("checkRoleChange() role = %q, want %q", role.Alias, tt.role)
+			}
+		})
+	}
+}
+
+func TestSetUserBanned(t *testing.T) {
+	const (
+		callerID = 1
+		memberID = 2
+		adminID  = 3
+		orgA     = 10
+		orgB     = 20
+	)
+
+	moderator := roles.NewMask(roles.CanBanUsers)
+	admin := moderator.With(roles.CanManageRoles)
+
+	tests := []struct {
+		name    string
+		mask    roles.Mask
+		org     int32
+		profile int32
+		wantErr error
+	}{
+		{"bans member", moderator, orgA, memberID, nil},
+		{"without permission", roles.NewMask(roles.CanUpdateUserRole), orgA, memberID, e.ErrForbiddenAction},
+		{"own ban", moderator, orgA, callerID, e.ErrForbiddenAction},
+		{"more privileged user", moderator, orgA, adminID, e.ErrUserMorePrivileged},
+		{"user of another organization", moderator, orgB, adminID, e.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeUsers{members: map[int32]map[int32]*UserModel{
+				orgA: {
+					callerID: {ID: callerID, PermissionMask: moderator},
+					memberID: {ID: memberID},
+					adminID:  {ID: adminID, PermissionMask: admin},
+				},
+				orgB: {
+					memberID: {ID: memberID},
+				},
+			}}
+			states := &fakeTokenStates{}
+
+			u := New(
+				slog.New(slog.NewTextHandler(io.Discard, nil)),
+				users, nil, nil, nil, nil, nil, nil, nil, states, nil, 0,
+			)
+
+			err := u.SetUserBanned(context.Background(), &SetUserBannedRequest{
+				UserID:         callerID,
+				OrgID:          tt.org,
+				PermissionMask: tt.mask,
+				ProfileID:      tt.profile,
+				Banned:         true,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetUserBanned() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if !users.members[tt.org][tt.profile].IsBanned {
+				t.Errorf("user is not banned in organization %d", tt.org)
+			}
+
+			for orgID, members := range users.members {
+				if orgID != tt.org && members[tt.profile] != nil && members[tt.profile].IsBanned {
+					t.Errorf("user is banned in organization %d", orgID)
+				}
+			}
+
+			if len(states.invalidated) != 1 || states.invalidated[0] != tt.profile {
+				t.Errorf("invalidated token states = %v, want [%d]", states.invalidated, tt.profile)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package usecases
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/AleksandrVishniakov/jwt-auth/internal/e"
+	"github.com/AleksandrVishniakov/jwt-auth/internal/roles"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	defaultUsersPageSize = 50
+	maxUsersPageSize     = 100
+)
+
+// ListUsers lists a page of the users of the caller's organization. One user
+// more than asked for is fetched to tell whether another page follows.
+func (u *Usecase) ListUsers(
+	ctx context.Context,
+	req *ListUsersRequest,
+) (*ListUsersResponse, error) {
+	const src = "Usecase.ListUsers"
+	log := u.log.With(slog.String("src", src))
+	log.Debug("listing users", slog.String("sort", string(req.Sort)))
+
+	if !roles.HasPermission(req.PermissionMask, roles.CanSeeProfiles) {
+		return nil, e.ErrForbiddenAction
+	}
+
+	users, err := u.storage.ListUsers(ctx, &UserFilter{
+		OrgID:       req.OrgID,
+		Role:        req.Role,
+		LoginPrefix: req.LoginPrefix,
+		CreatedFrom: req.CreatedFrom,
+		CreatedTo:   req.CreatedTo,
+		Sort:        req.Sort,
+		After:       req.After,
+		Limit:       req.Limit + 1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to list users: %w", src, err)
+	}
+
+	resp := &ListUsersResponse{}
+	if len(users) > req.Limit {
+		users = users[:req.Limit]
+
+		last := users[len(users)-1]
+		resp.NextCursor, err = encodeUserCursor(req.Sort, &UserCursor{
+			ID:        last.ID,
+			Login:     last.Login,
+			CreatedAt: last.CreatedAt,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to encode cursor: %w", src, err)
+		}
+	}
+
+	resp.Users = make([]*UserListItemResponse, 0, len(users))
+	for _, user := range users {
+		resp.Users = append(resp.Users, &UserListItemResponse{
+			ID:        user.ID,
+			Login:     user.Login,
+			CreatedAt: user.CreatedAt,
+			IsBanned:  user.IsBanned,
+		})
+	}
+
+	return resp, nil
+}
+
+// ChangePassword replaces the password of the caller once the current one is
+// confirmed. Every session of the user ends, the tokens issued before go
+// stale and the refresh tokens are revoked, so the caller gets a new pair.
+func (u *Usecase) ChangePassword(
+	ctx context.Context,
+	req *ChangePasswordRequest,
+) (*ChangePasswordResponse, error) {
+	const src = "Usecase.ChangePassword"
+	log := u.log.With(slog.String("src", src))
+	log.Debug("changing password", slog.Int("id", int(req.UserID)))
+
+	user, err := u.storage.GetUserById(ctx, req.OrgID, req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get user: %w", src, err)
+	}
+
+	if user.IsBanned {
+		return nil, e.ErrUserBanned
+	}
+
+	err = checkPassword(user, req.CurrentPassword)
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return nil, fmt.Errorf("%s: %w", src, e.ErrWrongPassword)
+		}
+
+		return nil, fmt.Errorf("%s: failed to compare password: %w", src, err)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to generate password hash: %w", src, err)
+	}
+
+	err = u.storage.ChangePassword(ctx, user.ID, hash)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to change password: %w", src, err)
+	}
+
+	u.tokenStates.Invalidate(user.ID)
+
+	// The token version moved on, the new tokens must carry it.
+	user, err = u.storage.GetUserById(ctx, req.OrgID, req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get user: %w", src, err)
+	}
+
+	token, err := u.tokenGenerator.Token(newTokenSubject(user, req.ClientID))
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to generate token: %w", src, err)
+	}
+
+	refreshToken, err := u.issueRefreshToken(ctx, user.ID, user.OrgID, req.ClientID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to issue refresh token: %w", src, err)
+	}
+
+	return &ChangePasswordResponse{
+		ID:           user.ID,
+		Token:        token,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// userCursor is the wire form of a cursor. It keeps the sort it was issued
+// for, so that a cursor cannot resume a listing ordered another way.
+type userCursor struct {
+	Sort      UserSort  `json:"s"`
+	ID        int32     `json:"i"`
+	Login     string    `json:"l,omitempty"`
+	CreatedAt time.Time `json:"c,omitempty"`
+}
+
+func encodeUserCursor(sort UserSort, cursor *UserCursor) (string, error) {
+	wire := userCursor{
+		Sort: sort,
+		ID:   cursor.ID,
+	}
+
+	switch sort {
+	case SortByLogin, SortByLoginDesc:
+		wire.Login = cursor.Login
+	case SortByCreatedAt, SortByCreatedAtDesc:
+		wire.CreatedAt = cursor.CreatedAt
+	}
+
+	data, err := json.Marshal(wire)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeUserCursor(sort UserSort, cursor string) (*UserCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var wire userCursor
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	if wire.Sort != sort {
+		return nil, errors.New("cursor was issued for another sort")
+	}
+
+	return &UserCursor{
+		ID:        wire.ID,
+		Login:     wire.Login,
+		CreatedAt: wire.CreatedAt,
+	}, nil
+}
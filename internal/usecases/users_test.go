@@ -0,0 +1,68 @@
+package usecases
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUserCursorRoundTrip(t *testing.T) {
+	cursor := &UserCursor{
+		ID:        42,
+		Login:     "alice",
+		CreatedAt: time.Date(2026, 10, 17, 12, 30, 0, 123456789, time.UTC),
+	}
+
+	tests := []struct {
+		sort UserSort
+		want UserCursor
+	}{
+		{SortByLogin, UserCursor{ID: 42, Login: "alice"}},
+		{SortByLoginDesc, UserCursor{ID: 42, Login: "alice"}},
+		{SortByCreatedAt, UserCursor{ID: 42, CreatedAt: cursor.CreatedAt}},
+		{SortByCreatedAtDesc, UserCursor{ID: 42, CreatedAt: cursor.CreatedAt}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.sort), func(t *testing.T) {
+			encoded, err := encodeUserCursor(tt.sort, cursor)
+			if err != nil {
+				t.Fatalf("encodeUserCursor: %v", err)
+			}
+
+			req, err := NewListUsersRequest(0, nil, "", "", time.Time{}, time.Time{}, string(tt.sort), encoded, 0)
+			if err != nil {
+				t.Fatalf("NewListUsersRequest: %v", err)
+			}
+
+			got := req.After
+			if got.ID != tt.want.ID || got.Login != tt.want.Login || !got.CreatedAt.Equal(tt.want.CreatedAt) {
+				t.Errorf("decoded cursor = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserCursorRejected(t *testing.T) {
+	byLogin, err := encodeUserCursor(SortByLogin, &UserCursor{ID: 1, Login: "alice"})
+	if err != nil {
+		t.Fatalf("encodeUserCursor: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		sort   UserSort
+		cursor string
+	}{
+		{"another sort", SortByLoginDesc, byLogin},
+		{"not base64", SortByLogin, "not a cursor!"},
+		{"not json", SortByLogin, "bm90IGpzb24"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeUserCursor(tt.sort, tt.cursor); err == nil {
+				t.Error("cursor was accepted")
+			}
+		})
+	}
+}